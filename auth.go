@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to a request. The client
+// calls it before every attempt, retries included.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Refresher is implemented by authenticators whose
+// credentials expire. When a request is answered with 401
+// the client calls Refresh with that request, as it was
+// authenticated, and sends the request once more.
+type Refresher interface {
+	Refresh(rejected *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator.
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f(req).
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// WithAuthenticator makes the client authenticate every
+// request with a.
+func WithAuthenticator(a Authenticator) Option {
+	return func(o *options) error {
+		if a == nil {
+			return errors.New("authenticator is nil")
+		}
+		o.auth = a
+		return nil
+	}
+}
+
+// BasicAuth returns an Authenticator sending HTTP basic
+// credentials.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+// BearerToken returns an Authenticator sending a static
+// bearer token.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// HeaderAuth returns an Authenticator setting header name
+// to the value returned by fn, e.g. an API key read from a
+// secret store.
+func HeaderAuth(name string, fn func(ctx context.Context) (string, error)) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		v, err := fn(req.Context())
+		if err != nil {
+			return err
+		}
+		req.Header.Set(name, v)
+		return nil
+	})
+}
+
+// TokenFunc fetches a new bearer token and its expiry. A
+// zero expiry means the token is used until a request is
+// answered with 401.
+type TokenFunc func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// tokenExpiryDelta renews tokens a bit before they expire
+// so they do not lapse in flight.
+const tokenExpiryDelta = 10 * time.Second
+
+// RefreshingToken is an Authenticator sending a bearer
+// token obtained from a TokenFunc. The token is fetched
+// again once it expires or the server answers 401.
+type RefreshingToken struct {
+	fetch TokenFunc
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewRefreshingToken returns a RefreshingToken using fetch.
+func NewRefreshingToken(fetch TokenFunc) *RefreshingToken {
+	return &RefreshingToken{fetch: fetch}
+}
+
+// Authenticate sets the Authorization header of req,
+// fetching a token first if there is no valid one.
+func (t *RefreshingToken) Authenticate(req *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token == "" || (!t.expiry.IsZero() && time.Now().Add(tokenExpiryDelta).After(t.expiry)) {
+		if err := t.refresh(req.Context()); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return nil
+}
+
+// Refresh fetches a new token, unless the token rejected
+// was sent with has been replaced already. Requests
+// answered 401 together thus fetch a single token.
+func (t *RefreshingToken) Refresh(rejected *http.Request) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rejected.Header.Get("Authorization") != "Bearer "+t.token {
+		return nil
+	}
+	return t.refresh(rejected.Context())
+}
+
+func (t *RefreshingToken) refresh(ctx context.Context) error {
+	token, expiry, err := t.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("token func returned an empty token")
+	}
+	t.token = token
+	t.expiry = expiry
+	return nil
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer accepts requests carrying the bearer token
+// valid and answers 401 to the others.
+type tokenServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	valid  string
+	tokens []string
+	bodies []string
+}
+
+func newTokenServer(t *testing.T, valid string) *tokenServer {
+	t.Helper()
+	s := &tokenServer{valid: valid}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		token := r.Header.Get("Authorization")
+		s.tokens = append(s.tokens, token)
+		s.bodies = append(s.bodies, string(b))
+		if token != "Bearer "+s.valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errors":["unauthorized"]}`))
+			return
+		}
+		w.Write([]byte(`{"jobs":[],"jobid":"b3c2ba2a8d9c5a4e7d1f0e6b9a8c7d6e"}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// countingTokens returns a TokenFunc handing out "t1",
+// "t2", ... and the number of tokens fetched so far.
+func countingTokens() (TokenFunc, func() int) {
+	var mu sync.Mutex
+	n := 0
+	fetch := func(context.Context) (string, time.Time, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return fmt.Sprintf("t%d", n), time.Time{}, nil
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return n
+	}
+	return fetch, count
+}
+
+func TestRefreshOn401(t *testing.T) {
+	srv := newTokenServer(t, "t2")
+	fetch, fetched := countingTokens()
+	c, err := New(srv.URL, WithAuthenticator(NewRefreshingToken(fetch)), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if fetched() != 2 {
+		t.Errorf("fetched %d tokens, want 2", fetched())
+	}
+	if want := []string{"Bearer t1", "Bearer t2"}; fmt.Sprint(srv.tokens) != fmt.Sprint(want) {
+		t.Errorf("server saw tokens %v, want %v", srv.tokens, want)
+	}
+
+	// the refreshed token is kept
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if fetched() != 2 {
+		t.Errorf("fetched %d tokens after a second request, want 2", fetched())
+	}
+}
+
+func TestRefreshOnlyOnce(t *testing.T) {
+	srv := newTokenServer(t, "never")
+	fetch, fetched := countingTokens()
+	c, err := New(srv.URL, WithAuthenticator(NewRefreshingToken(fetch)), WithRetryPolicy(testRetryPolicy(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Jobs()
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("Jobs() error = %v, want a 401", err)
+	}
+	if fetched() != 2 {
+		t.Errorf("fetched %d tokens, want 2", fetched())
+	}
+	if len(srv.tokens) != 2 {
+		t.Errorf("server got %d requests, want 2", len(srv.tokens))
+	}
+}
+
+func TestRefreshResendsNonIdempotent(t *testing.T) {
+	srv := newTokenServer(t, "t2")
+	fetch, _ := countingTokens()
+	c, err := New(srv.URL, WithAuthenticator(NewRefreshingToken(fetch)), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RunJar(RunOpts{JarID: testJarID, Parallelism: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.bodies) != 2 || srv.bodies[0] != srv.bodies[1] {
+		t.Errorf("server got bodies %q, want the same body twice", srv.bodies)
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	srv := newTokenServer(t, "t2")
+	errFetch := errors.New("token endpoint down")
+	calls := 0
+	fetch := func(context.Context) (string, time.Time, error) {
+		calls++
+		if calls > 1 {
+			return "", time.Time{}, errFetch
+		}
+		return "t1", time.Time{}, nil
+	}
+	c, err := New(srv.URL, WithAuthenticator(NewRefreshingToken(fetch)), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Jobs(); !errors.Is(err, errFetch) {
+		t.Fatalf("Jobs() error = %v, want %v", err, errFetch)
+	}
+	if len(srv.tokens) != 1 {
+		t.Errorf("server got %d requests, want 1", len(srv.tokens))
+	}
+}
+
+func TestRefreshSkipsReplacedToken(t *testing.T) {
+	fetch, fetched := countingTokens()
+	a := NewRefreshingToken(fetch)
+	first, _ := http.NewRequest("GET", "http://jobmanager:8081/jobs", nil)
+	second, _ := http.NewRequest("GET", "http://jobmanager:8081/jobs", nil)
+	for _, req := range []*http.Request{first, second} {
+		if err := a.Authenticate(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// both were sent with t1 and answered 401
+	for _, req := range []*http.Request{first, second} {
+		if err := a.Refresh(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetched() != 2 {
+		t.Errorf("fetched %d tokens, want 2", fetched())
+	}
+}
+
+func TestRefreshConcurrent(t *testing.T) {
+	srv := newTokenServer(t, "t2")
+	fetch, fetched := countingTokens()
+	c, err := New(srv.URL, WithAuthenticator(NewRefreshingToken(fetch)), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// fetch t1 up front so that every request below is
+	// either rejected with t1 or sent with t2
+	if err := c.client.auth.Authenticate(httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Jobs(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fetched() != 2 {
+		t.Errorf("fetched %d tokens, want 2", fetched())
+	}
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// CheckpointConfigResp is the checkpointing configuration
+// of a job. Durations are in milliseconds.
+type CheckpointConfigResp struct {
+	// Mode is "exactly_once" or "at_least_once".
+	Mode                        string                  `json:"mode"`
+	Interval                    Duration                `json:"interval"`
+	Timeout                     Duration                `json:"timeout"`
+	MinPause                    Duration                `json:"min_pause"`
+	MaxConcurrent               int                     `json:"max_concurrent"`
+	Externalization             ExternalizedCheckpoints `json:"externalization"`
+	StateBackend                string                  `json:"state_backend"`
+	CheckpointStorage           string                  `json:"checkpoint_storage"`
+	UnalignedCheckpoints        bool                    `json:"unaligned_checkpoints"`
+	TolerableFailedCheckpoints  int                     `json:"tolerable_failed_checkpoints"`
+	AlignedCheckpointTimeout    Duration                `json:"aligned_checkpoint_timeout"`
+	CheckpointsAfterTasksFinish bool                    `json:"checkpoints_after_tasks_finish"`
+	StateChangelogEnabled       bool                    `json:"state_changelog_enabled"`
+	ChangelogStorage            string                  `json:"changelog_storage,omitempty"`
+}
+
+// ExternalizedCheckpoints tells whether checkpoints are
+// retained outside of the job and when they are deleted.
+type ExternalizedCheckpoints struct {
+	Enabled              bool `json:"enabled"`
+	DeleteOnCancellation bool `json:"delete_on_cancellation"`
+}
+
+// CheckpointConfig returns the checkpointing configuration
+// of a job.
+func (c *Client) CheckpointConfig(jobID string) (CheckpointConfigResp, error) {
+	return c.CheckpointConfigContext(context.Background(), jobID)
+}
+
+// CheckpointConfigContext is like CheckpointConfig but
+// carries a context.
+func (c *Client) CheckpointConfigContext(ctx context.Context, jobID string) (CheckpointConfigResp, error) {
+	var r CheckpointConfigResp
+	uri := fmt.Sprintf("/jobs/%s/checkpoints/config", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// CheckpointDetails returns a checkpoint of a job and its
+// statistics per job vertex.
+func (c *Client) CheckpointDetails(jobID string, checkpointID int64) (CheckpointStatistics, error) {
+	return c.CheckpointDetailsContext(context.Background(), jobID, checkpointID)
+}
+
+// CheckpointDetailsContext is like CheckpointDetails but
+// carries a context.
+func (c *Client) CheckpointDetailsContext(ctx context.Context, jobID string, checkpointID int64) (CheckpointStatistics, error) {
+	var r CheckpointStatistics
+	uri := fmt.Sprintf("/jobs/%s/checkpoints/details/%d", jobID, checkpointID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// CheckpointSubtasksResp is the answer of
+// CheckpointSubtasks.
+type CheckpointSubtasksResp struct {
+	TaskCheckpointStatistics
+	Summary  SubtaskCheckpointSummary      `json:"summary"`
+	Subtasks []SubtaskCheckpointStatistics `json:"subtasks"`
+}
+
+// SubtaskCheckpointSummary aggregates the checkpoint
+// statistics of the subtasks of a job vertex.
+type SubtaskCheckpointSummary struct {
+	CheckpointedSize   MinMaxAvg                  `json:"checkpointed_size"`
+	StateSize          MinMaxAvg                  `json:"state_size"`
+	End2EndDuration    MinMaxAvg                  `json:"end_to_end_duration"`
+	CheckpointDuration CheckpointDurationSummary  `json:"checkpoint_duration"`
+	Alignment          CheckpointAlignmentSummary `json:"alignment"`
+	StartDelay         MinMaxAvg                  `json:"start_delay"`
+}
+
+// CheckpointDurationSummary aggregates the synchronous and
+// asynchronous checkpoint durations of subtasks.
+type CheckpointDurationSummary struct {
+	Sync  MinMaxAvg `json:"sync"`
+	Async MinMaxAvg `json:"async"`
+}
+
+// CheckpointAlignmentSummary aggregates the barrier
+// alignment of subtasks.
+type CheckpointAlignmentSummary struct {
+	Buffered  MinMaxAvg `json:"buffered"`
+	Processed MinMaxAvg `json:"processed"`
+	Persisted MinMaxAvg `json:"persisted"`
+	Duration  MinMaxAvg `json:"duration"`
+}
+
+// Statuses of a subtask checkpoint.
+const (
+	SubtaskCheckpointCompleted       = "completed"
+	SubtaskCheckpointPendingOrFailed = "pending_or_failed"
+)
+
+// SubtaskCheckpointStatistics describes the checkpoint of
+// one subtask. Only Index and Status are set while the
+// subtask has not acknowledged the checkpoint.
+type SubtaskCheckpointStatistics struct {
+	Index               int                        `json:"index"`
+	Status              string                     `json:"status"`
+	AckTimestamp        Timestamp                  `json:"ack_timestamp,omitempty"`
+	End2EndDuration     Duration                   `json:"end_to_end_duration"`
+	CheckpointedSize    int64                      `json:"checkpointed_size"`
+	StateSize           int64                      `json:"state_size"`
+	Checkpoint          SubtaskCheckpointDuration  `json:"checkpoint"`
+	Alignment           SubtaskCheckpointAlignment `json:"alignment"`
+	StartDelay          Duration                   `json:"start_delay"`
+	UnalignedCheckpoint bool                       `json:"unaligned_checkpoint"`
+	Aborted             bool                       `json:"aborted"`
+}
+
+// SubtaskCheckpointDuration is the synchronous and
+// asynchronous part of a subtask checkpoint.
+type SubtaskCheckpointDuration struct {
+	Sync  Duration `json:"sync"`
+	Async Duration `json:"async"`
+}
+
+// SubtaskCheckpointAlignment is the barrier alignment of a
+// subtask. Sizes are in bytes.
+type SubtaskCheckpointAlignment struct {
+	Buffered  int64    `json:"buffered"`
+	Processed int64    `json:"processed"`
+	Persisted int64    `json:"persisted"`
+	Duration  Duration `json:"duration"`
+}
+
+// SlowestAlignment returns the acknowledged subtask with
+// the longest barrier alignment, nil if there is none.
+func (r CheckpointSubtasksResp) SlowestAlignment() *SubtaskCheckpointStatistics {
+	return r.slowest(func(s SubtaskCheckpointStatistics) Duration {
+		return s.Alignment.Duration
+	})
+}
+
+// SlowestSync returns the acknowledged subtask with the
+// longest synchronous checkpoint part, nil if there is
+// none.
+func (r CheckpointSubtasksResp) SlowestSync() *SubtaskCheckpointStatistics {
+	return r.slowest(func(s SubtaskCheckpointStatistics) Duration {
+		return s.Checkpoint.Sync
+	})
+}
+
+func (r CheckpointSubtasksResp) slowest(d func(SubtaskCheckpointStatistics) Duration) *SubtaskCheckpointStatistics {
+	var max *SubtaskCheckpointStatistics
+	for i := range r.Subtasks {
+		s := &r.Subtasks[i]
+		if s.Status != SubtaskCheckpointCompleted {
+			continue
+		}
+		if max == nil || d(*s) > d(*max) {
+			max = s
+		}
+	}
+	return max
+}
+
+// CheckpointSubtasks returns the checkpoint statistics of
+// every subtask of a job vertex.
+func (c *Client) CheckpointSubtasks(jobID string, checkpointID int64, vertexID string) (CheckpointSubtasksResp, error) {
+	return c.CheckpointSubtasksContext(context.Background(), jobID, checkpointID, vertexID)
+}
+
+// CheckpointSubtasksContext is like CheckpointSubtasks but
+// carries a context.
+func (c *Client) CheckpointSubtasksContext(ctx context.Context, jobID string, checkpointID int64, vertexID string) (CheckpointSubtasksResp, error) {
+	var r CheckpointSubtasksResp
+	uri := fmt.Sprintf("/jobs/%s/checkpoints/details/%d/subtasks/%s", jobID, checkpointID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// CheckpointType selects the kind of a manually triggered
+// checkpoint.
+type CheckpointType string
+
+// Checkpoint types.
+const (
+	CheckpointConfigured  CheckpointType = "CONFIGURED"
+	CheckpointFull        CheckpointType = "FULL"
+	CheckpointIncremental CheckpointType = "INCREMENTAL"
+)
+
+// TriggerCheckpoint triggers a checkpoint of a job. It
+// needs Flink 1.19 or later. The result of the returned
+// operation carries the ID of the checkpoint.
+func (c *Client) TriggerCheckpoint(jobID string, typ CheckpointType) (*Operation, error) {
+	return c.TriggerCheckpointContext(context.Background(), jobID, typ)
+}
+
+// TriggerCheckpointContext is like TriggerCheckpoint but
+// carries a context.
+func (c *Client) TriggerCheckpointContext(ctx context.Context, jobID string, typ CheckpointType) (*Operation, error) {
+	type checkpointReq struct {
+		CheckpointType CheckpointType `json:"checkpointType,omitempty"`
+	}
+	var r TriggerResp
+
+	d := checkpointReq{
+		CheckpointType: typ,
+	}
+	data := new(bytes.Buffer)
+	if err := json.NewEncoder(data).Encode(d); err != nil {
+		return nil, err
+	}
+	uri := fmt.Sprintf("/jobs/%s/checkpoints", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.url(uri),
+		data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	b, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	status := fmt.Sprintf("/jobs/%s/checkpoints/%s", jobID, r.RequestID)
+	return c.newOperation(r.RequestID, status), nil
+}
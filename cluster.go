@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// OverviewResp is an overview over the cluster.
+type OverviewResp struct {
+	TaskManagers        int    `json:"taskmanagers"`
+	TaskManagersBlocked int    `json:"taskmanagers-blocked"`
+	SlotsTotal          int    `json:"slots-total"`
+	SlotsAvailable      int    `json:"slots-available"`
+	SlotsFreeAndBlocked int    `json:"slots-free-and-blocked"`
+	JobsRunning         int    `json:"jobs-running"`
+	JobsFinished        int    `json:"jobs-finished"`
+	JobsCancelled       int    `json:"jobs-cancelled"`
+	JobsFailed          int    `json:"jobs-failed"`
+	FlinkVersion        string `json:"flink-version"`
+	FlinkCommit         string `json:"flink-commit"`
+}
+
+// Overview returns an overview over the cluster: task
+// managers, slots, jobs by status and flink version.
+func (c *Client) Overview() (OverviewResp, error) {
+	return c.OverviewContext(context.Background())
+}
+
+// OverviewContext is like Overview but carries a context.
+func (c *Client) OverviewContext(ctx context.Context) (OverviewResp, error) {
+	var r OverviewResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/overview"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// DatasetsResp is the answer of Datasets.
+type DatasetsResp struct {
+	DataSets []Dataset `json:"dataSets"`
+}
+
+// Dataset is a cluster partition, i.e. an intermediate
+// result cached across jobs.
+type Dataset struct {
+	ID         string `json:"id"`
+	IsComplete bool   `json:"isComplete"`
+}
+
+// Datasets returns all cluster data sets.
+func (c *Client) Datasets() (DatasetsResp, error) {
+	return c.DatasetsContext(context.Background())
+}
+
+// DatasetsContext is like Datasets but carries a context.
+func (c *Client) DatasetsContext(ctx context.Context) (DatasetsResp, error) {
+	var r DatasetsResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/datasets"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// DeleteDataset triggers the deletion of a cluster data
+// set.
+func (c *Client) DeleteDataset(datasetID string) (*Operation, error) {
+	return c.DeleteDatasetContext(context.Background(), datasetID)
+}
+
+// DeleteDatasetContext is like DeleteDataset but carries a
+// context.
+func (c *Client) DeleteDatasetContext(ctx context.Context, datasetID string) (*Operation, error) {
+	var r TriggerResp
+	uri := fmt.Sprintf("/datasets/%s", datasetID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return c.DeleteDatasetOperation(r.RequestID), nil
+}
+
+// DeleteDatasetOperation returns a handle on a deletion
+// triggered by DeleteDataset, given its request ID.
+func (c *Client) DeleteDatasetOperation(triggerID string) *Operation {
+	uri := fmt.Sprintf("/datasets/delete/%s", triggerID)
+	return c.newOperation(triggerID, uri)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned when the flink REST API answers
+// with a non-2xx status code.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Method and URL identify the failed request. A
+	// password in the URL is replaced by "xxxxx".
+	Method string
+	URL    string
+
+	// Errors holds the messages of flink's
+	// {"errors": [...]} response body. When the body is not
+	// in that format it holds the raw body instead.
+	Errors []string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: http status %d", e.Method, e.URL, e.StatusCode)
+	if len(e.Errors) > 0 {
+		msg += ": " + strings.Join(e.Errors, "; ")
+	}
+	return msg
+}
+
+func newAPIError(req *http.Request, code int, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: code,
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+	}
+	var r struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &r); err == nil && len(r.Errors) > 0 {
+		e.Errors = r.Errors
+	} else if s := strings.TrimSpace(string(body)); s != "" {
+		e.Errors = []string{s}
+	}
+	return e
+}
+
+// StatusCode returns the HTTP status code carried by err,
+// or 0 if err is not an *APIError.
+func StatusCode(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 answer, e.g. an
+// unknown job or jar ID.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409 answer, e.g. an
+// operation on a job in a state that does not allow it.
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsBadRequest reports whether err is a 400 answer.
+func IsBadRequest(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
+
+// IsServerError reports whether err is a 5xx answer.
+func IsServerError(err error) bool {
+	code := StatusCode(err)
+	return code >= 500 && code < 600
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorRedactsPassword(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusNotFound, body: `{"errors":["Job not found."]}`})
+	addr := strings.Replace(srv.URL, "http://", "http://flink:s3cret@", 1)
+	c, err := New(addr, WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Job(testJobID)
+	if !IsNotFound(err) {
+		t.Fatalf("Job() error = %v, want a 404", err)
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("error %q leaks the password", err)
+	}
+	want := "GET http://flink:xxxxx@" + strings.TrimPrefix(srv.URL, "http://") + "/jobs/" + testJobID + ": http status 404: Job not found."
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+}
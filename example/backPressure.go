@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// back pressure test
+	jobID := "8ea123d2bdc3064f36b92889e43803ee"
+	job, err := c.Job(jobID)
+	if err != nil {
+		panic(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, v := range job.Vertices {
+		bp, err := c.WaitBackPressure(ctx, jobID, v.ID, time.Second)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(v.Name, bp.Level)
+	}
+}
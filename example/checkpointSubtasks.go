@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// checkpoint subtasks test
+	jobID := "2bd452ba193d1575a4acc9ed09f896ea"
+	cps, err := c.Checkpoints(jobID)
+	if err != nil {
+		panic(err)
+	}
+	if cps.Latest.Completed == nil {
+		return
+	}
+	cp := cps.Latest.Completed
+	for vertexID := range cp.Tasks {
+		v, err := c.CheckpointSubtasks(jobID, cp.ID, vertexID)
+		if err != nil {
+			panic(err)
+		}
+		if s := v.SlowestAlignment(); s != nil {
+			fmt.Println(vertexID, s.Index, s.Alignment.Duration)
+		}
+	}
+}
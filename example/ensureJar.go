@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+	ctx := context.Background()
+
+	// ensure jar test, the second call reuses the upload
+	for i := 0; i < 2; i++ {
+		id, err := c.EnsureJar(ctx, "./testdata/test.jar")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(id)
+	}
+
+	// prune jar test
+	jars, err := c.PruneJars(ctx, api.PruneJarsOpts{
+		OlderThan: time.Hour,
+		DryRun:    true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	for _, j := range jars {
+		fmt.Println(j.ID, j.Uploaded)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	// FLINK_API_HA is a comma separated list of addresses
+	addrs := strings.Split(os.Getenv("FLINK_API_HA"), ",")
+	c, err := api.NewHA(addrs, api.WithEndpointHook(func(req *http.Request, endpoint string) {
+		fmt.Println(req.URL.Path, "served by", endpoint)
+	}))
+	if err != nil {
+		panic(err)
+	}
+
+	// HA jobs test
+	jobs, err := c.Jobs()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(jobs, c.Endpoint())
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// job exceptions test
+	ex, err := c.JobExceptions("8ea123d2bdc3064f36b92889e43803ee", 10)
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range ex.ExceptionHistory.Entries {
+		fmt.Println(e.Timestamp, e.TaskName, e.ExceptionName)
+	}
+}
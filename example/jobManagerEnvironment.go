@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// job manager environment test
+	env, err := c.JobManagerEnvironment()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(env.JVM.Version, env.JVM.Options)
+}
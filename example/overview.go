@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// overview test
+	v, err := c.Overview()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%d/%d slots available\n", v.SlotsAvailable, v.SlotsTotal)
+}
@@ -13,8 +13,13 @@ func main() {
 		panic(err)
 	}
 
+	opts := api.RunOpts{
+		JarID:       "8c0c2226-b532-4d9b-b698-8aa649694bb9_test.jar",
+		ProgramArg:  []string{"--input", "a,b,c"},
+		Parallelism: 4,
+	}
 	// plan test
-	resp, err := c.PlanJar("8c0c2226-b532-4d9b-b698-8aa649694bb9_test.jar")
+	resp, err := c.PlanJar(opts)
 	if err != nil {
 		panic(err)
 	}
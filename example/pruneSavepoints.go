@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// prune savepoints dry run test
+	opts := api.PruneSavepointsOpts{
+		KeepLast:  3,
+		OlderThan: 7 * 24 * time.Hour,
+		DryRun:    true,
+	}
+	v, err := c.PruneSavepoints(context.Background(), "2bd452ba193d1575a4acc9ed09f896ea", opts)
+	if err != nil {
+		panic(err)
+	}
+	for _, s := range v {
+		fmt.Println(s.Time, s.Path)
+	}
+}
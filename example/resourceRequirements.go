@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// resource requirements test
+	jobID := "8ea123d2bdc3064f36b92889e43803ee"
+	reqs, err := c.ResourceRequirements(jobID)
+	if err != nil {
+		panic(err)
+	}
+	for id, r := range reqs {
+		r.Parallelism.UpperBound *= 2
+		reqs[id] = r
+	}
+	if err := c.SetResourceRequirements(jobID, reqs); err != nil {
+		panic(err)
+	}
+	fmt.Println(reqs)
+}
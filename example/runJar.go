@@ -14,7 +14,11 @@ func main() {
 	}
 
 	opts := api.RunOpts{
-		JarID: "8c0c2226-b532-4d9b-b698-8aa649694bb9_test.jar",
+		JarID:      "8c0c2226-b532-4d9b-b698-8aa649694bb9_test.jar",
+		ProgramArg: []string{"--input", "a,b,c"},
+		FlinkConfiguration: map[string]string{
+			"pipeline.name": "test",
+		},
 	}
 	// run test
 	resp, err := c.RunJar(opts)
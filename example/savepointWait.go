@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	jobID := "2bd452ba193d1575a4acc9ed09f896ea"
+	v, err := c.SavePoints(jobID, "test", false)
+	if err != nil {
+		panic(err)
+	}
+
+	// wait for the savepoint test
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	res, err := v.Operation().Wait(ctx, time.Second)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(res.Location)
+}
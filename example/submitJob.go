@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// path of a JobGraph serialized ahead of time
+	opts := api.SubmitOpts{
+		JobGraphFile: os.Getenv("FLINK_JOB_GRAPH"),
+		JarFiles:     []string{"./testdata/test.jar"},
+	}
+	// submit job test
+	resp, err := c.SubmitJob(opts)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(resp.JobID())
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// task manager log test
+	tms, err := c.TaskManagers()
+	if err != nil {
+		panic(err)
+	}
+	for _, tm := range tms.TaskManagers {
+		log, err := c.TaskManagerLog(tm.ID)
+		if err != nil {
+			panic(err)
+		}
+		io.Copy(os.Stdout, log)
+		log.Close()
+	}
+}
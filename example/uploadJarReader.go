@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// streaming upload test
+	f, err := os.Open("./testdata/test.jar")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
+	id, err := c.UploadJarReader(context.Background(), "test.jar", f, fi.Size(), func(sent, total int64) {
+		fmt.Printf("\r%d/%d bytes", sent, total)
+	})
+	fmt.Println()
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(id)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flink-go/api"
+)
+
+func main() {
+	c, err := api.New(os.Getenv("FLINK_API"))
+	if err != nil {
+		panic(err)
+	}
+
+	// vertex test
+	jobID := "8ea123d2bdc3064f36b92889e43803ee"
+	job, err := c.Job(jobID)
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range job.Vertices {
+		tms, err := c.VertexTaskManagers(jobID, v.ID)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(v.Name, tms)
+	}
+}
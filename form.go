@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// formPart is a part of a multipart/form-data body, either
+// a plain field holding value or, when open is set, a file
+// named fileName. The content of a file is read from what
+// open returns each time the body is built; size is its
+// length in bytes, -1 if unknown.
+type formPart struct {
+	field string
+	value string
+
+	fileName string
+	open     func() (io.ReadCloser, error)
+	size     int64
+}
+
+// form is a multipart/form-data body whose files are
+// streamed rather than buffered. The framing around the
+// files is built up front.
+type form struct {
+	contentType string
+	parts       []formPart
+
+	// frames[i] precedes the i-th file, the last frame
+	// ends the body
+	frames [][]byte
+	length int64
+}
+
+func newForm(parts []formPart) (*form, error) {
+	f := &form{parts: parts}
+	framing := &bytes.Buffer{}
+	writer := multipart.NewWriter(framing)
+	cut := func() {
+		f.frames = append(f.frames, append([]byte(nil), framing.Bytes()...))
+		framing.Reset()
+	}
+
+	for _, p := range parts {
+		if p.open == nil {
+			if err := writer.WriteField(p.field, p.value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := writer.CreateFormFile(p.field, p.fileName); err != nil {
+			return nil, err
+		}
+		cut()
+		if p.size < 0 || f.length < 0 {
+			f.length = -1
+		} else {
+			f.length += p.size
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	cut()
+
+	if f.length >= 0 {
+		for _, b := range f.frames {
+			f.length += int64(len(b))
+		}
+	}
+	f.contentType = writer.FormDataContentType()
+	return f, nil
+}
+
+// body opens the files of the form and returns the body
+// streaming them. Closing it closes the files.
+func (f *form) body() (io.ReadCloser, error) {
+	readers := make([]io.Reader, 0, 2*len(f.frames))
+	var files multiCloser
+	i := 0
+	for _, p := range f.parts {
+		if p.open == nil {
+			continue
+		}
+		r, err := p.open()
+		if err != nil {
+			files.Close()
+			return nil, err
+		}
+		files = append(files, r)
+		readers = append(readers, bytes.NewReader(f.frames[i]), r)
+		i++
+	}
+	readers = append(readers, bytes.NewReader(f.frames[i]))
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(readers...), files}, nil
+}
+
+// newFormRequest returns a POST request sending f to path.
+// The body is built again on retries only when replayable
+// is set.
+func (c *Client) newFormRequest(ctx context.Context, path string, f *form, replayable bool) (*http.Request, error) {
+	body, err := f.body()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url(path), body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", f.contentType)
+	if f.length >= 0 {
+		req.ContentLength = f.length
+	}
+	if replayable {
+		req.GetBody = f.body
+	}
+	return req, nil
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var first error
+	for _, c := range m {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeTimeout bounds the /config health check made
+// against a candidate endpoint during failover.
+const probeTimeout = 5 * time.Second
+
+// NewHA returns a flink client for a high-availability
+// cluster reachable through several job manager addresses.
+//
+// Requests go to one endpoint until it fails with a
+// connection error or a 5xx status. The client then probes
+// the other endpoints with GET /config, sticks to the first
+// healthy one and, when the request may be replayed (see
+// RetryPolicy), sends it again there.
+func NewHA(addrs []string, opts ...Option) (*Client, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no flink address given")
+	}
+	var o options
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, err
+		}
+	}
+
+	bases := make([]*url.URL, 0, len(addrs))
+	for _, addr := range addrs {
+		base, err := baseURL(addr, o)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+
+	eps := &endpoints{urls: bases}
+	hc, err := newHttpClient(o, eps)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		Addr:      addrs[0],
+		endpoints: eps,
+		client:    hc,
+	}, nil
+}
+
+// Endpoint returns the base URL of the endpoint requests
+// are currently sent to.
+func (c *Client) Endpoint() string {
+	return c.endpoints.current().String()
+}
+
+// WithEndpointHook calls fn with the base URL of the
+// endpoint that answered each request, including answers
+// with an error status.
+func WithEndpointHook(fn func(req *http.Request, endpoint string)) Option {
+	return func(o *options) error {
+		o.endpointHook = fn
+		return nil
+	}
+}
+
+// endpoints is the set of job manager base URLs of a
+// client and the one currently in use. urls is never
+// modified after creation.
+type endpoints struct {
+	mu     sync.Mutex
+	urls   []*url.URL
+	active int
+}
+
+func (e *endpoints) len() int {
+	return len(e.urls)
+}
+
+func (e *endpoints) at(i int) *url.URL {
+	return e.urls[i]
+}
+
+func (e *endpoints) current() *url.URL {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.urls[e.active]
+}
+
+// rebase moves u onto the active endpoint and returns its
+// index. u must have been built from one of the endpoints.
+func (e *endpoints) rebase(u *url.URL) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.urls) == 1 {
+		return 0
+	}
+	for _, b := range e.urls {
+		if u.Scheme != b.Scheme || u.Host != b.Host || !strings.HasPrefix(u.Path, b.Path) {
+			continue
+		}
+		to := e.urls[e.active]
+		u.Scheme = to.Scheme
+		u.Host = to.Host
+		if u.RawPath != "" && strings.HasPrefix(u.RawPath, b.EscapedPath()) {
+			u.RawPath = to.EscapedPath() + u.RawPath[len(b.EscapedPath()):]
+		} else {
+			u.RawPath = ""
+		}
+		u.Path = to.Path + u.Path[len(b.Path):]
+		break
+	}
+	return e.active
+}
+
+// failover marks endpoint failed as unhealthy and switches
+// to the next endpoint passing probe. It reports whether
+// the active endpoint is now a different one, which is also
+// the case when another request already failed over.
+//
+// The lock is not held while probing, so other requests
+// keep going meanwhile; if one of them fails over first,
+// its choice is kept.
+func (e *endpoints) failover(failed int, probe func(*url.URL) bool) bool {
+	if len(e.urls) == 1 {
+		return false
+	}
+	if !e.isActive(failed) {
+		return true
+	}
+	for i := 1; i < len(e.urls); i++ {
+		next := (failed + i) % len(e.urls)
+		if !probe(e.urls[next]) {
+			continue
+		}
+		e.mu.Lock()
+		if e.active == failed {
+			e.active = next
+		}
+		e.mu.Unlock()
+		return true
+	}
+	return !e.isActive(failed)
+}
+
+func (e *endpoints) isActive(i int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.active == i
+}
+
+// probe reports whether base answers GET /config.
+func (c *httpClient) probe(ctx context.Context, base *url.URL) bool {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", base.String()+"/config", nil)
+	if err != nil {
+		return false
+	}
+	c.prepare(req)
+	if err := c.authenticate(req); err != nil {
+		return false
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode/100 == 2
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFailover(t *testing.T) {
+	down := newScriptedServer(t, unavailable)
+	up := newScriptedServer(t, jobsOK)
+
+	var answered []string
+	hook := func(_ *http.Request, endpoint string) {
+		answered = append(answered, endpoint)
+	}
+	c, err := NewHA([]string{down.URL, up.URL}, WithRetryPolicy(testRetryPolicy(2)), WithEndpointHook(hook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint() != up.URL {
+		t.Fatalf("Endpoint() = %s, want %s", c.Endpoint(), up.URL)
+	}
+	if down.hits() != 1 {
+		t.Errorf("down endpoint got %d requests, want 1", down.hits())
+	}
+	if want := []string{down.URL, up.URL}; len(answered) != 2 || answered[0] != want[0] || answered[1] != want[1] {
+		t.Errorf("endpoint hook saw %v, want %v", answered, want)
+	}
+
+	// the client sticks to the healthy endpoint
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if down.hits() != 1 {
+		t.Errorf("down endpoint got %d requests after failover, want 1", down.hits())
+	}
+}
+
+func TestFailoverClosedEndpoint(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	up := newScriptedServer(t, jobsOK)
+
+	c, err := NewHA([]string{closed.URL, up.URL}, WithRetryPolicy(testRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint() != up.URL {
+		t.Fatalf("Endpoint() = %s, want %s", c.Endpoint(), up.URL)
+	}
+}
+
+func TestFailoverAllDown(t *testing.T) {
+	a := newScriptedServer(t, unavailable)
+	b := newScriptedServer(t, unavailable)
+
+	c, err := NewHA([]string{a.URL, b.URL}, WithRetryPolicy(testRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Jobs()
+	if StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("Jobs() error = %v, want a 503", err)
+	}
+	if c.Endpoint() != a.URL {
+		t.Errorf("Endpoint() = %s, want %s", c.Endpoint(), a.URL)
+	}
+}
+
+// TestFailoverProbeUnlocked checks that the client stays
+// usable while a failover probe is in flight.
+func TestFailoverProbeUnlocked(t *testing.T) {
+	down := newScriptedServer(t, unavailable)
+
+	probing := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/config" {
+			once.Do(func() { close(probing) })
+			<-release
+		}
+		w.Write([]byte(`{"jobs":[]}`))
+	}))
+	defer up.Close()
+	defer func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	}()
+
+	c, err := NewHA([]string{down.URL, up.URL}, WithRetryPolicy(testRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Jobs()
+		done <- err
+	}()
+
+	select {
+	case <-probing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no probe was sent")
+	}
+	endpoint := make(chan string, 1)
+	go func() {
+		endpoint <- c.Endpoint()
+	}()
+	select {
+	case ep := <-endpoint:
+		if ep != down.URL {
+			t.Errorf("Endpoint() during probe = %s, want %s", ep, down.URL)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Endpoint() blocked while probing")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint() != up.URL {
+		t.Errorf("Endpoint() = %s, want %s", c.Endpoint(), up.URL)
+	}
+}
@@ -2,43 +2,96 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
 // Client reprents flink REST API client
 type Client struct {
-	// Addr reprents flink job manager server address
+	// Addr is the flink job manager address the client was
+	// created with, the first one for NewHA.
+	//
+	// Deprecated: requests no longer go to Addr, so
+	// assigning it has no effect. Use Endpoint for the
+	// address requests are sent to.
 	Addr string
 
-	client *httpClient
+	endpoints *endpoints
+	client    *httpClient
 }
 
-// New returns a flink client
-func New(addr string) (*Client, error) {
-	return &Client{
-		Addr:   addr,
-		client: newHttpClient(),
-	}, nil
+// New returns a flink client. The address may be a bare
+// "host:port" or a full URL such as
+// "https://example.com/flink/".
+func New(addr string, opts ...Option) (*Client, error) {
+	return NewHA([]string{addr}, opts...)
 }
 
-func (c *Client) url(path string) string {
-	if strings.HasPrefix(c.Addr, "http") {
-		return fmt.Sprintf("%s%s", c.Addr, path)
+// baseURL returns the URL every request path to addr is
+// appended to.
+func baseURL(addr string, o options) (*url.URL, error) {
+	scheme := "http"
+	if o.tlsEnabled() {
+		scheme = "https"
+	}
+	base, err := parseAddr(addr, scheme)
+	if err != nil {
+		return nil, err
+	}
+	if o.basePath != "" {
+		base.Path = path.Join("/", base.Path, o.basePath)
+	}
+	base.Path = strings.TrimRight(base.Path, "/")
+	return base, nil
+}
+
+// parseAddr turns a flink address into a base URL, using
+// scheme when the address has none.
+func parseAddr(addr, scheme string) (*url.URL, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("flink address is empty")
+	}
+	raw := addr
+	if !strings.Contains(raw, "://") {
+		raw = scheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flink address %q: %v", addr, err)
 	}
-	return fmt.Sprintf("http://%s%s", c.Addr, path)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid flink address %q: unsupported scheme %q", addr, u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid flink address %q: missing host", addr)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid flink address %q: query and fragment are not allowed", addr)
+	}
+	return u, nil
+}
+
+func (c *Client) url(path string) string {
+	return c.endpoints.current().String() + path
 }
 
 // Shutdown shutdown the flink cluster
 func (c *Client) Shutdown() error {
-	req, err := http.NewRequest("DELETE", c.url("/cluster"), nil)
+	return c.ShutdownContext(context.Background())
+}
+
+// ShutdownContext is like Shutdown but carries a context.
+func (c *Client) ShutdownContext(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.url("/cluster"), nil)
 	if err != nil {
 		return err
 	}
@@ -46,22 +99,33 @@ func (c *Client) Shutdown() error {
 	return err
 }
 
-type configResp struct {
-	RefreshInterval int64    `json:"refresh-interval"`
+// ConfigResp is the configuration of the web UI.
+type ConfigResp struct {
+	RefreshInterval Duration `json:"refresh-interval"`
 	TimezoneName    string   `json:"timezone-name"`
 	TimezoneOffset  int64    `json:"timezone-offset"`
 	FlinkVersion    string   `json:"flink-version"`
 	FlinkRevision   string   `json:"flink-revision"`
-	Features        features `json:"features"`
+	Features        Features `json:"features"`
 }
-type features struct {
-	WebSubmit bool `json:"web-submit"`
+
+// Features tells which web UI features are enabled.
+type Features struct {
+	WebSubmit  bool `json:"web-submit"`
+	WebCancel  bool `json:"web-cancel"`
+	WebRescale bool `json:"web-rescale"`
+	WebHistory bool `json:"web-history"`
 }
 
 // Config returns the configuration of the WebUI
-func (c *Client) Config() (configResp, error) {
-	var r configResp
-	req, err := http.NewRequest("GET", c.url("/config"), nil)
+func (c *Client) Config() (ConfigResp, error) {
+	return c.ConfigContext(context.Background())
+}
+
+// ConfigContext is like Config but carries a context.
+func (c *Client) ConfigContext(ctx context.Context) (ConfigResp, error) {
+	var r ConfigResp
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url("/config"), nil)
 	if err != nil {
 		return r, err
 	}
@@ -73,61 +137,151 @@ func (c *Client) Config() (configResp, error) {
 	return r, err
 }
 
-type uploadResp struct {
+// UploadResp is the answer of UploadJar. FileName is the
+// path of the jar on the job manager.
+type UploadResp struct {
 	FileName string `json:"filename"`
 	Status   string `json:"status"`
 }
 
-// Upload uploads jar file
-func (c *Client) UploadJar(fpath string) (uploadResp, error) {
-	var r uploadResp
+// JarID returns the ID of the uploaded jar, the last
+// element of FileName.
+func (r UploadResp) JarID() string {
+	return path.Base(strings.Replace(r.FileName, "\\", "/", -1))
+}
+
+// ProgressFunc is called while an upload proceeds with the
+// number of bytes of the jar sent so far and its total
+// size, -1 if unknown.
+type ProgressFunc func(sent, total int64)
+
+// UploadJar uploads jar file
+func (c *Client) UploadJar(fpath string) (UploadResp, error) {
+	return c.UploadJarContext(context.Background(), fpath)
+}
+
+// UploadJarContext is like UploadJar but carries a context.
+// Cancelling the context aborts the upload.
+func (c *Client) UploadJarContext(ctx context.Context, fpath string) (UploadResp, error) {
 	file, err := os.Open(fpath)
 	if err != nil {
-		return r, err
+		return UploadResp{}, err
 	}
 	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return UploadResp{}, err
+	}
+	return c.uploadJar(ctx, filepath.Base(fpath), file, fi.Size(), nil)
+}
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("jarfile", filepath.Base(file.Name()))
-	io.Copy(part, file)
-	writer.Close()
+// UploadJarReader uploads a jar read from r under the file
+// name name, and returns its jar ID. The jar is streamed
+// rather than buffered; size is its length in bytes, or -1
+// if unknown. progress, if not nil, is called as the
+// upload proceeds.
+//
+// The upload can only be retried when r is also an
+// io.Seeker.
+func (c *Client) UploadJarReader(ctx context.Context, name string, r io.Reader, size int64, progress ProgressFunc) (string, error) {
+	resp, err := c.uploadJar(ctx, name, r, size, progress)
+	if err != nil {
+		return "", err
+	}
+	return resp.JarID(), nil
+}
+
+func (c *Client) uploadJar(ctx context.Context, name string, r io.Reader, size int64, progress ProgressFunc) (UploadResp, error) {
+	var resp UploadResp
+	if name == "" {
+		return resp, fmt.Errorf("jar file name is empty")
+	}
 
-	req, err := http.NewRequest("POST", c.url("/jars/upload"), body)
+	seeker, replayable := r.(io.Seeker)
+	var start int64
+	if replayable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return resp, err
+		}
+	}
+	f, err := newForm([]formPart{{
+		field:    "jarfile",
+		fileName: name,
+		size:     size,
+		open: func() (io.ReadCloser, error) {
+			if replayable {
+				if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			return ioutil.NopCloser(&progressReader{r: r, total: size, progress: progress}), nil
+		},
+	}})
 	if err != nil {
-		return r, err
+		return resp, err
 	}
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req, err := c.newFormRequest(ctx, "/jars/upload", f, replayable)
+	if err != nil {
+		return resp, err
+	}
+
 	b, err := c.client.Do(req)
 	if err != nil {
-		return r, err
+		return resp, err
 	}
-	err = json.Unmarshal(b, &r)
-	return r, err
+	err = json.Unmarshal(b, &resp)
+	return resp, err
+}
+
+// progressReader reports the bytes read from r to
+// progress.
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress ProgressFunc
 }
 
-type jarsResp struct {
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 && p.progress != nil {
+		p.sent += int64(n)
+		p.progress(p.sent, p.total)
+	}
+	return n, err
+}
+
+// JarsResp is the answer of Jars.
+type JarsResp struct {
 	Address string    `json:"address"`
-	Files   []jarFile `json:"files"`
+	Files   []JarFile `json:"files"`
 }
 
-type jarFile struct {
-	ID       string  `json:"id"`
-	Name     string  `json:"name"`
-	Uploaded int64   `json:"uploaded"`
-	Entries  []entry `json:"entry"`
+// JarFile is an uploaded jar.
+type JarFile struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Uploaded Timestamp  `json:"uploaded"`
+	Entries  []JarEntry `json:"entry"`
 }
 
-type entry struct {
+// JarEntry is an entry class found in a jar.
+type JarEntry struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
 
 // Jars eturns a list of all jars previously uploaded
 // via '/jars/upload'
-func (c *Client) Jars() (jarsResp, error) {
-	var r jarsResp
-	req, err := http.NewRequest("GET", c.url("/jars"), nil)
+func (c *Client) Jars() (JarsResp, error) {
+	return c.JarsContext(context.Background())
+}
+
+// JarsContext is like Jars but carries a context.
+func (c *Client) JarsContext(ctx context.Context) (JarsResp, error) {
+	var r JarsResp
+	req, err := http.NewRequestWithContext(ctx, "GET", c.url("/jars"), nil)
 	if err != nil {
 		return r, err
 	}
@@ -141,8 +295,13 @@ func (c *Client) Jars() (jarsResp, error) {
 
 // DeleteJar deletes a jar file
 func (c *Client) DeleteJar(jarid string) error {
+	return c.DeleteJarContext(context.Background(), jarid)
+}
+
+// DeleteJarContext is like DeleteJar but carries a context.
+func (c *Client) DeleteJarContext(ctx context.Context, jarid string) error {
 	uri := fmt.Sprintf("/jars/%s", jarid)
-	req, err := http.NewRequest("DELETE", c.url(uri), nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.url(uri), nil)
 	if err != nil {
 		return err
 	}
@@ -150,26 +309,30 @@ func (c *Client) DeleteJar(jarid string) error {
 	return err
 }
 
-type planResp struct {
-	Plan plan `json:"plan"`
+// PlanResp is the answer of PlanJar.
+type PlanResp struct {
+	Plan Plan `json:"plan"`
 }
 
-type plan struct {
-	JID   string `json:"jid"`
-	Name  string `json:"name"`
-	Nodes []node `json:"nodes"`
+// Plan is the dataflow plan of a job.
+type Plan struct {
+	JID   string     `json:"jid"`
+	Name  string     `json:"name"`
+	Nodes []PlanNode `json:"nodes"`
 }
 
-type node struct {
-	ID               string  `json:"id"`
-	Parallelism      int     `json:"parallelism"`
-	Operator         string  `json:"operator"`
-	OperatorStrategy string  `json:"operator_strategy"`
-	Description      string  `json:"description"`
-	Inputs           []input `json:"inputs"`
+// PlanNode is a job vertex in a Plan.
+type PlanNode struct {
+	ID               string      `json:"id"`
+	Parallelism      int         `json:"parallelism"`
+	Operator         string      `json:"operator"`
+	OperatorStrategy string      `json:"operator_strategy"`
+	Description      string      `json:"description"`
+	Inputs           []PlanInput `json:"inputs"`
 }
 
-type input struct {
+// PlanInput is an edge between two PlanNodes.
+type PlanInput struct {
 	Num          int    `json:"num"`
 	ID           string `json:"id"`
 	ShipStrategy string `json:"ship_strategy"`
@@ -177,15 +340,37 @@ type input struct {
 }
 
 // PlanJar returns the dataflow plan of a job contained
-// in a jar previously uploaded via '/jars/upload'.
-// Todo: support more args.
-func (c *Client) PlanJar(jarid string) (planResp, error) {
-	var r planResp
-	uri := fmt.Sprintf("/jars/%s/plan", jarid)
-	req, err := http.NewRequest("GET", c.url(uri), nil)
+// in a jar previously uploaded via '/jars/upload'. The
+// plan is built with the program arguments, entry class,
+// parallelism and configuration of opts; its savepoint
+// settings are ignored. Although sent as a POST, it is
+// retried like a read-only call.
+func (c *Client) PlanJar(opts RunOpts) (PlanResp, error) {
+	return c.PlanJarContext(context.Background(), opts)
+}
+
+// PlanJarContext is like PlanJar but carries a context.
+func (c *Client) PlanJarContext(ctx context.Context, opts RunOpts) (PlanResp, error) {
+	var r PlanResp
+	if opts.JarID == "" {
+		return r, fmt.Errorf("jar id is required")
+	}
+	// flink rejects unknown fields in the plan request
+	opts.SavepointPath = ""
+	opts.AllowNonRestoredState = false
+	opts.RestoreMode = ""
+	data, err := opts.body()
 	if err != nil {
 		return r, err
 	}
+	uri := fmt.Sprintf("/jars/%s/plan", opts.JarID)
+	// planning has no side effect, so the POST is retried
+	// like a GET
+	req, err := http.NewRequestWithContext(idempotent(ctx), "POST", c.url(uri), data)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
 	b, err := c.client.Do(req)
 	if err != nil {
 		return r, err
@@ -194,10 +379,12 @@ func (c *Client) PlanJar(jarid string) (planResp, error) {
 	return r, err
 }
 
-type runResp struct {
-	ID string
+// RunResp is the answer of RunJar.
+type RunResp struct {
+	ID string `json:"jobid"`
 }
 
+// RunOpts are the arguments of RunJar and PlanJar.
 type RunOpts struct {
 	// JarID: String value that identifies a jar. When
 	// uploading the jar a path is returned, where the
@@ -215,7 +402,14 @@ type RunOpts struct {
 	// job from.
 	SavepointPath string
 
-	// programArg (optional): list of program arguments.
+	// RestoreMode (optional): how the job takes ownership
+	// of the savepoint it is restored from. Needs flink
+	// 1.15 or later.
+	RestoreMode RestoreMode
+
+	// ProgramArg (optional): list of program arguments.
+	// Each argument is sent as is, so it may contain
+	// commas or spaces.
 	ProgramArg []string
 
 	// EntryClass (optional): String value that specifies
@@ -227,32 +421,88 @@ type RunOpts struct {
 	// Parallelism (optional): Positive integer value that
 	// specifies the desired parallelism for the job.
 	Parallelism int
+
+	// JobID (optional): 32-character hexadecimal string
+	// pinning the ID of the submitted job.
+	JobID string
+
+	// FlinkConfiguration (optional): configuration
+	// overriding the cluster configuration for this job.
+	// Needs flink 1.17 or later.
+	FlinkConfiguration map[string]string
 }
 
-// RunJar submits a job by running a jar previously
-// uploaded via '/jars/upload'.
-func (c *Client) RunJar(opts RunOpts) (runResp, error) {
-	var r runResp
-	uri := fmt.Sprintf("/jars/%s/run", opts.JarID)
-	req, err := http.NewRequest("POST", c.url(uri), nil)
-	q := req.URL.Query()
-	if opts.SavepointPath != "" {
-		q.Add("savepointPath", opts.SavepointPath)
-		q.Add("allowNonRestoredState", strconv.FormatBool(opts.AllowNonRestoredState))
+// RestoreMode tells how a job restored from a savepoint
+// treats its files.
+type RestoreMode string
+
+// Restore modes.
+const (
+	// RestoreClaim makes the job own the savepoint and
+	// delete it once it is subsumed.
+	RestoreClaim RestoreMode = "CLAIM"
+
+	// RestoreNoClaim leaves the savepoint alone; the
+	// first checkpoint of the job is a full one.
+	RestoreNoClaim RestoreMode = "NO_CLAIM"
+
+	// RestoreLegacy is the behavior of flink before 1.15.
+	RestoreLegacy RestoreMode = "LEGACY"
+)
+
+// jarReq is the JSON body of the jar run and plan
+// requests.
+type jarReq struct {
+	EntryClass            string            `json:"entryClass,omitempty"`
+	ProgramArgsList       []string          `json:"programArgsList,omitempty"`
+	Parallelism           int               `json:"parallelism,omitempty"`
+	JobID                 string            `json:"jobId,omitempty"`
+	AllowNonRestoredState bool              `json:"allowNonRestoredState,omitempty"`
+	SavepointPath         string            `json:"savepointPath,omitempty"`
+	RestoreMode           RestoreMode       `json:"restoreMode,omitempty"`
+	FlinkConfiguration    map[string]string `json:"flinkConfiguration,omitempty"`
+}
+
+func (opts RunOpts) body() (*bytes.Buffer, error) {
+	d := jarReq{
+		EntryClass:            opts.EntryClass,
+		ProgramArgsList:       opts.ProgramArg,
+		Parallelism:           opts.Parallelism,
+		JobID:                 opts.JobID,
+		AllowNonRestoredState: opts.AllowNonRestoredState,
+		SavepointPath:         opts.SavepointPath,
+		RestoreMode:           opts.RestoreMode,
+		FlinkConfiguration:    opts.FlinkConfiguration,
 	}
-	if len(opts.ProgramArg) > 0 {
-		q.Add("programArg", strings.Join(opts.ProgramArg, ","))
+	data := new(bytes.Buffer)
+	if err := json.NewEncoder(data).Encode(d); err != nil {
+		return nil, err
 	}
-	if opts.EntryClass != "" {
-		q.Add("entry-class", opts.EntryClass)
+	return data, nil
+}
+
+// RunJar submits a job by running a jar previously
+// uploaded via '/jars/upload'.
+func (c *Client) RunJar(opts RunOpts) (RunResp, error) {
+	return c.RunJarContext(context.Background(), opts)
+}
+
+// RunJarContext is like RunJar but carries a context.
+func (c *Client) RunJarContext(ctx context.Context, opts RunOpts) (RunResp, error) {
+	var r RunResp
+	if opts.JarID == "" {
+		return r, fmt.Errorf("jar id is required")
 	}
-	if opts.Parallelism > 0 {
-		q.Add("parallelism", strconv.Itoa(opts.Parallelism))
+	data, err := opts.body()
+	if err != nil {
+		return r, err
 	}
-	req.URL.RawQuery = q.Encode()
+	uri := fmt.Sprintf("/jars/%s/run", opts.JarID)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url(uri), data)
 	if err != nil {
 		return r, err
 	}
+	req.Header.Set("Content-Type", "application/json")
 	b, err := c.client.Do(req)
 	if err != nil {
 		return r, err
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRunJarBody(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RunOpts
+		want map[string]interface{}
+	}{
+		{
+			name: "defaults",
+			opts: RunOpts{JarID: testJarID},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "all options",
+			opts: RunOpts{
+				JarID:                 testJarID,
+				EntryClass:            "com.example.Etl",
+				ProgramArg:            []string{"--input", "s3://bucket/a,b", "--name", "nightly run", `quoted "arg"`},
+				Parallelism:           4,
+				JobID:                 testJobID,
+				SavepointPath:         "s3://bucket/savepoints/savepoint-1",
+				AllowNonRestoredState: true,
+				RestoreMode:           RestoreNoClaim,
+				FlinkConfiguration:    map[string]string{"pipeline.name": "etl, nightly"},
+			},
+			want: map[string]interface{}{
+				"entryClass":            "com.example.Etl",
+				"programArgsList":       []interface{}{"--input", "s3://bucket/a,b", "--name", "nightly run", `quoted "arg"`},
+				"parallelism":           4.0,
+				"jobId":                 testJobID,
+				"savepointPath":         "s3://bucket/savepoints/savepoint-1",
+				"allowNonRestoredState": true,
+				"restoreMode":           "NO_CLAIM",
+				"flinkConfiguration":    map[string]interface{}{"pipeline.name": "etl, nightly"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, runOK)
+			c, err := New(srv.URL, WithRetryPolicy(NoRetry()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.RunJar(tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if want := "POST /jars/" + testJarID + "/run"; srv.uris[0] != want {
+				t.Errorf("request %s, want %s without a query", srv.uris[0], want)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(srv.bodies[0]), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadJarProgress(t *testing.T) {
+	jar := bytes.Repeat([]byte("jar"), 100000)
+	size := int64(len(jar))
+	tests := []struct {
+		name      string
+		responses []scriptedResponse
+		attempts  int
+	}{
+		{"single attempt", []scriptedResponse{uploadOK}, 1},
+		{"replayed", []scriptedResponse{unavailable, uploadOK}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, tt.responses...)
+			p := testRetryPolicy(3)
+			p.RetryNonIdempotent = true
+			c, err := New(srv.URL, WithRetryPolicy(p))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// runs[i] are the sent counts reported during
+			// the i-th attempt
+			var runs [][]int64
+			progress := func(sent, total int64) {
+				if total != size {
+					t.Errorf("progress total = %d, want %d", total, size)
+				}
+				if len(runs) == 0 || sent <= runs[len(runs)-1][len(runs[len(runs)-1])-1] {
+					runs = append(runs, nil)
+				}
+				runs[len(runs)-1] = append(runs[len(runs)-1], sent)
+			}
+			if _, err := c.UploadJarReader(context.Background(), "test.jar", bytes.NewReader(jar), size, progress); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(runs) != tt.attempts {
+				t.Fatalf("progress restarted %d times, want %d", len(runs), tt.attempts)
+			}
+			for i, run := range runs {
+				if run[0] <= 0 || run[0] > size {
+					t.Errorf("attempt %d: first progress = %d, want it counted from zero", i+1, run[0])
+				}
+				if last := run[len(run)-1]; last != size {
+					t.Errorf("attempt %d: last progress = %d, want %d", i+1, last, size)
+				}
+			}
+		})
+	}
+}
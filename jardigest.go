@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// digestName matches the name of a jar uploaded by
+// EnsureJar, which embeds the SHA-256 of its content.
+var digestName = regexp.MustCompile(`-sha256-([0-9a-f]{64})\.jar$`)
+
+// JarDigest returns the hex encoded SHA-256 embedded in the
+// name of a jar uploaded by EnsureJar, or "" for other jars.
+func JarDigest(name string) string {
+	m := digestName.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// digestJarName returns the name EnsureJar uploads the jar
+// fpath with content digest under.
+func digestJarName(fpath, digest string) string {
+	stem := strings.TrimSuffix(filepath.Base(fpath), ".jar")
+	return fmt.Sprintf("%s-sha256-%s.jar", stem, digest)
+}
+
+// EnsureJar makes sure the jar file fpath is uploaded and
+// returns its jar ID. The jar is uploaded under a name that
+// embeds the SHA-256 of its content; if a jar with the same
+// digest is already listed by Jars, the most recently
+// uploaded one is reused instead of uploading a copy.
+//
+// Concurrent calls for the same jar may still upload it
+// more than once; PruneJars removes such duplicates.
+func (c *Client) EnsureJar(ctx context.Context, fpath string) (string, error) {
+	file, err := os.Open(fpath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	jars, err := c.JarsContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	var found *JarFile
+	for i, j := range jars.Files {
+		if JarDigest(j.Name) != digest {
+			continue
+		}
+		if found == nil || j.Uploaded > found.Uploaded {
+			found = &jars.Files[i]
+		}
+	}
+	if found != nil {
+		return found.ID, nil
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return c.UploadJarReader(ctx, digestJarName(fpath, digest), file, size, nil)
+}
+
+// PruneJarsOpts are the arguments of PruneJars.
+//
+// Flink does not tell which jar a job was run from, so
+// PruneJars cannot check running jobs by itself. Without
+// InUse it only deletes duplicate uploads of the same
+// content, and jars no job references are kept as long as
+// they are the most recent copy. Set InUse to also delete
+// those.
+type PruneJarsOpts struct {
+	// InUse (optional): reports whether a jar is still
+	// needed, e.g. by a running job the caller started
+	// from it. Jars in use are never deleted. When set,
+	// every other jar uploaded by EnsureJar is deleted;
+	// when nil, only duplicates are, keeping the most
+	// recent copy of each jar.
+	InUse func(JarFile) bool
+
+	// OlderThan: only jars uploaded longer ago than this
+	// are deleted, sparing jars EnsureJar has just
+	// uploaded for a job not yet running.
+	OlderThan time.Duration
+
+	// DryRun (optional): only return the jars that would
+	// be deleted.
+	DryRun bool
+}
+
+// PruneJars deletes jars uploaded by EnsureJar that are no
+// longer needed. Unless opts.InUse is set, that is only
+// duplicate copies, see PruneJarsOpts. Jars uploaded
+// otherwise are left alone. It returns the deleted jars, or those
+// that would be deleted in a dry run, newest first. On
+// error the jars deleted so far are returned.
+func (c *Client) PruneJars(ctx context.Context, opts PruneJarsOpts) ([]JarFile, error) {
+	jars, err := c.JarsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := pruneJarCandidates(jars.Files, opts, time.Now())
+	if opts.DryRun {
+		return candidates, nil
+	}
+
+	var deleted []JarFile
+	for _, j := range candidates {
+		if err := c.DeleteJarContext(ctx, j.ID); err != nil && !IsNotFound(err) {
+			return deleted, err
+		}
+		deleted = append(deleted, j)
+	}
+	return deleted, nil
+}
+
+// pruneJarCandidates returns the jars PruneJars deletes,
+// newest first.
+func pruneJarCandidates(files []JarFile, opts PruneJarsOpts, now time.Time) []JarFile {
+	var managed []JarFile
+	for _, j := range files {
+		if JarDigest(j.Name) != "" {
+			managed = append(managed, j)
+		}
+	}
+	sort.SliceStable(managed, func(i, j int) bool {
+		return managed[i].Uploaded > managed[j].Uploaded
+	})
+
+	var candidates []JarFile
+	seen := make(map[string]bool)
+	cutoff := now.Add(-opts.OlderThan)
+	for _, j := range managed {
+		digest := JarDigest(j.Name)
+		latest := !seen[digest]
+		seen[digest] = true
+
+		if opts.InUse != nil && opts.InUse(j) {
+			continue
+		}
+		if opts.InUse == nil && latest {
+			continue
+		}
+		if !j.Uploaded.Time().Before(cutoff) {
+			continue
+		}
+		candidates = append(candidates, j)
+	}
+	return candidates
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPruneJarCandidates(t *testing.T) {
+	now := time.Unix(1718000000, 0)
+	digestA, digestB := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	jar := func(id, digest string, age time.Duration) JarFile {
+		name := id + ".jar"
+		if digest != "" {
+			name = fmt.Sprintf("etl-sha256-%s.jar", digest)
+		}
+		return JarFile{
+			ID:       id + "_" + name,
+			Name:     name,
+			Uploaded: Timestamp(now.Add(-age).UnixNano() / int64(time.Millisecond)),
+		}
+	}
+	files := []JarFile{
+		jar("a-2h", digestA, 2*time.Hour),
+		jar("b-1h", digestB, time.Hour),
+		jar("a-1h", digestA, time.Hour),
+		jar("a-3h", digestA, 3*time.Hour),
+		jar("plain", "", 5*time.Hour),
+	}
+	inUse := func(ids ...string) func(JarFile) bool {
+		return func(j JarFile) bool {
+			for _, id := range ids {
+				if strings.HasPrefix(j.ID, id+"_") {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts PruneJarsOpts
+		want []string
+	}{
+		{
+			name: "duplicates",
+			want: []string{"a-2h", "a-3h"},
+		},
+		{
+			name: "duplicates older than",
+			opts: PruneJarsOpts{OlderThan: 150 * time.Minute},
+			want: []string{"a-3h"},
+		},
+		{
+			name: "in use",
+			opts: PruneJarsOpts{InUse: inUse("a-2h")},
+			want: []string{"b-1h", "a-1h", "a-3h"},
+		},
+		{
+			name: "nothing in use",
+			opts: PruneJarsOpts{InUse: inUse()},
+			want: []string{"b-1h", "a-1h", "a-2h", "a-3h"},
+		},
+		{
+			name: "in use older than",
+			opts: PruneJarsOpts{InUse: inUse("a-3h"), OlderThan: 90 * time.Minute},
+			want: []string{"a-2h"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, j := range pruneJarCandidates(files, tt.opts, now) {
+				got = append(got, strings.SplitN(j.ID, "_", 2)[0])
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("pruneJarCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureJar(t *testing.T) {
+	content := []byte("PK\x03\x04 etl jar")
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	fpath := filepath.Join(t.TempDir(), "etl.jar")
+	if err := ioutil.WriteFile(fpath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	digestName := "etl-sha256-" + digest + ".jar"
+
+	tests := []struct {
+		name       string
+		files      []JarFile
+		wantID     string
+		wantUpload bool
+	}{
+		{
+			name: "newest copy reused",
+			files: []JarFile{
+				{ID: "old_" + digestName, Name: digestName, Uploaded: 1718000000000},
+				{ID: "new_" + digestName, Name: digestName, Uploaded: 1718000500000},
+				{ID: "older_" + digestName, Name: digestName, Uploaded: 1717000000000},
+			},
+			wantID: "new_" + digestName,
+		},
+		{
+			name: "uploaded when missing",
+			files: []JarFile{
+				{ID: "plain_etl.jar", Name: "etl.jar", Uploaded: 1718000000000},
+				{ID: "other_etl-sha256-" + strings.Repeat("0", 64) + ".jar", Name: "etl-sha256-" + strings.Repeat("0", 64) + ".jar"},
+			},
+			wantID:     "1234_" + digestName,
+			wantUpload: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jars, err := json.Marshal(JarsResp{Files: tt.files})
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := newScriptedServer(t,
+				scriptedResponse{status: http.StatusOK, body: string(jars)},
+				scriptedResponse{status: http.StatusOK, body: `{"filename":"/tmp/flink-web/flink-web-upload/1234_` + digestName + `","status":"success"}`},
+			)
+			c, err := New(srv.URL, WithRetryPolicy(NoRetry()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := c.EnsureJar(context.Background(), fpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != tt.wantID {
+				t.Errorf("EnsureJar() = %s, want %s", id, tt.wantID)
+			}
+			if uploaded := srv.hits() == 2; uploaded != tt.wantUpload {
+				t.Fatalf("EnsureJar() uploaded = %v, want %v", uploaded, tt.wantUpload)
+			}
+			if tt.wantUpload {
+				upload := srv.bodies[1]
+				if !strings.Contains(upload, `filename="`+digestName+`"`) || !strings.Contains(upload, string(content)) {
+					t.Errorf("upload body %q, want %s named %s", upload, content, digestName)
+				}
+			}
+		})
+	}
+}
@@ -2,22 +2,36 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
-type kv struct {
+// KeyValue is a configuration entry.
+type KeyValue struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
 
 // JobManagerConfig returns the cluster configuration of
 // job manager server.
-func (c *Client) JobManagerConfig() ([]kv, error) {
-	var r []kv
-	req, err := http.NewRequest(
+func (c *Client) JobManagerConfig() ([]KeyValue, error) {
+	return c.JobManagerConfigContext(context.Background())
+}
+
+// JobManagerConfigContext is like JobManagerConfig but
+// carries a context.
+func (c *Client) JobManagerConfigContext(ctx context.Context) ([]KeyValue, error) {
+	var r []KeyValue
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url("/jobmanager/config"),
 		nil,
@@ -33,15 +47,39 @@ func (c *Client) JobManagerConfig() ([]kv, error) {
 	return r, err
 }
 
-type metric struct {
-	ID string `json:"id"`
+// Metric is a metric of a job manager, task manager, job
+// or vertex. Value is only set when the metric was
+// requested by name.
+type Metric struct {
+	ID    string `json:"id"`
+	Value string `json:"value,omitempty"`
+}
+
+// AggregatedMetric is a metric aggregated over several
+// jobs, task managers or subtasks. Only the requested
+// aggregations are set, the others are nil.
+type AggregatedMetric struct {
+	ID  string   `json:"id"`
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	Avg *float64 `json:"avg,omitempty"`
+	Sum *float64 `json:"sum,omitempty"`
 }
 
 // JobManagerMetrics provides access to job manager
-// metrics.
-func (c *Client) JobManagerMetrics() ([]metric, error) {
-	var r []metric
-	req, err := http.NewRequest(
+// metrics. Without arguments it lists the available
+// metrics, otherwise it returns the values of the given
+// ones.
+func (c *Client) JobManagerMetrics(metrics ...string) ([]Metric, error) {
+	return c.JobManagerMetricsContext(context.Background(), metrics...)
+}
+
+// JobManagerMetricsContext is like JobManagerMetrics but
+// carries a context.
+func (c *Client) JobManagerMetricsContext(ctx context.Context, metrics ...string) ([]Metric, error) {
+	var r []Metric
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url("/jobmanager/metrics"),
 		nil,
@@ -49,6 +87,11 @@ func (c *Client) JobManagerMetrics() ([]metric, error) {
 	if err != nil {
 		return r, err
 	}
+	if len(metrics) > 0 {
+		q := req.URL.Query()
+		q.Add("get", strings.Join(metrics, ","))
+		req.URL.RawQuery = q.Encode()
+	}
 	b, err := c.client.Do(req)
 	if err != nil {
 		return r, err
@@ -57,20 +100,28 @@ func (c *Client) JobManagerMetrics() ([]metric, error) {
 	return r, err
 }
 
-type jobsResp struct {
-	Jobs []job `json:"jobs"`
+// JobsResp is the answer of Jobs.
+type JobsResp struct {
+	Jobs []JobIDWithStatus `json:"jobs"`
 }
 
-type job struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+// JobIDWithStatus is a job ID and its current status.
+type JobIDWithStatus struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
 }
 
 // Jobs returns an overview over all jobs and their
 // current state.
-func (c *Client) Jobs() (jobsResp, error) {
-	var r jobsResp
-	req, err := http.NewRequest(
+func (c *Client) Jobs() (JobsResp, error) {
+	return c.JobsContext(context.Background())
+}
+
+// JobsContext is like Jobs but carries a context.
+func (c *Client) JobsContext(ctx context.Context) (JobsResp, error) {
+	var r JobsResp
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url("/jobs"),
 		nil,
@@ -86,9 +137,124 @@ func (c *Client) Jobs() (jobsResp, error) {
 	return r, err
 }
 
-// SubmitJob submits a job.
-func (c *Client) SubmitJob() error {
-	return fmt.Errorf("not implement")
+// SubmitOpts are the arguments of SubmitJob.
+type SubmitOpts struct {
+	// JobGraphFile: path of the serialized JobGraph.
+	JobGraphFile string
+
+	// JarFiles (optional): paths of the jars containing
+	// the user code of the job.
+	JarFiles []string
+
+	// Artifacts (optional): files registered in the
+	// distributed cache of the job.
+	Artifacts []Artifact
+}
+
+// Artifact is a file of the distributed cache.
+type Artifact struct {
+	// EntryName is the name the job registered the file
+	// under.
+	EntryName string
+
+	// Path is the path of the local file.
+	Path string
+}
+
+// SubmitResp is the answer of SubmitJob.
+type SubmitResp struct {
+	JobURL string `json:"jobUrl"`
+}
+
+// JobID returns the ID of the submitted job.
+func (r SubmitResp) JobID() string {
+	return path.Base(r.JobURL)
+}
+
+// SubmitJob submits a job from a JobGraph serialized
+// ahead of time, along with its jars and artifacts.
+func (c *Client) SubmitJob(opts SubmitOpts) (SubmitResp, error) {
+	return c.SubmitJobContext(context.Background(), opts)
+}
+
+// SubmitJobContext is like SubmitJob but carries a context.
+func (c *Client) SubmitJobContext(ctx context.Context, opts SubmitOpts) (SubmitResp, error) {
+	var r SubmitResp
+	if opts.JobGraphFile == "" {
+		return r, fmt.Errorf("job graph file is required")
+	}
+
+	type artifactName struct {
+		EntryName string `json:"entryName"`
+		FileName  string `json:"fileName"`
+	}
+	type submitReq struct {
+		JobGraphFileName     string         `json:"jobGraphFileName"`
+		JobJarFileNames      []string       `json:"jobJarFileNames"`
+		JobArtifactFileNames []artifactName `json:"jobArtifactFileNames"`
+	}
+
+	// flink matches the parts to the request by file name
+	files := []string{opts.JobGraphFile}
+	d := submitReq{
+		JobGraphFileName:     filepath.Base(opts.JobGraphFile),
+		JobJarFileNames:      []string{},
+		JobArtifactFileNames: []artifactName{},
+	}
+	for _, f := range opts.JarFiles {
+		files = append(files, f)
+		d.JobJarFileNames = append(d.JobJarFileNames, filepath.Base(f))
+	}
+	for _, a := range opts.Artifacts {
+		files = append(files, a.Path)
+		d.JobArtifactFileNames = append(d.JobArtifactFileNames, artifactName{
+			EntryName: a.EntryName,
+			FileName:  filepath.Base(a.Path),
+		})
+	}
+	seen := make(map[string]bool)
+	for _, f := range files {
+		name := filepath.Base(f)
+		if seen[name] {
+			return r, fmt.Errorf("duplicate file name %q in job submission", name)
+		}
+		seen[name] = true
+	}
+
+	reqJSON, err := json.Marshal(d)
+	if err != nil {
+		return r, err
+	}
+	parts := []formPart{{field: "request", value: string(reqJSON)}}
+	for i, fpath := range files {
+		fi, err := os.Stat(fpath)
+		if err != nil {
+			return r, err
+		}
+		fpath := fpath
+		parts = append(parts, formPart{
+			field:    fmt.Sprintf("file_%d", i),
+			fileName: filepath.Base(fpath),
+			size:     fi.Size(),
+			open: func() (io.ReadCloser, error) {
+				return os.Open(fpath)
+			},
+		})
+	}
+	f, err := newForm(parts)
+	if err != nil {
+		return r, err
+	}
+	req, err := c.newFormRequest(ctx, "/jobs", f, true)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
 }
 
 type JobMetricsOpts struct {
@@ -107,9 +273,16 @@ type JobMetricsOpts struct {
 }
 
 // JobMetrics provides access to aggregated job metrics.
-func (c *Client) JobMetrics(opts JobMetricsOpts) (map[string]interface{}, error) {
-	var r map[string]interface{}
-	req, err := http.NewRequest(
+func (c *Client) JobMetrics(opts JobMetricsOpts) ([]AggregatedMetric, error) {
+	return c.JobMetricsContext(context.Background(), opts)
+}
+
+// JobMetricsContext is like JobMetrics but
+// carries a context.
+func (c *Client) JobMetricsContext(ctx context.Context, opts JobMetricsOpts) ([]AggregatedMetric, error) {
+	var r []AggregatedMetric
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url("/jobs/metrics"),
 		nil,
@@ -140,38 +313,50 @@ func (c *Client) JobMetrics(opts JobMetricsOpts) (map[string]interface{}, error)
 	return r, err
 }
 
-type overviewResp struct {
-	Jobs []jobOverview `json:"jobs"`
+// JobsOverviewResp is the answer of JobsOverview.
+type JobsOverviewResp struct {
+	Jobs []JobOverview `json:"jobs"`
 }
 
-type jobOverview struct {
-	ID               string `json:"jid"`
-	Name             string `json:"name"`
-	State            string `json:"state"`
-	Start            int64  `json:"start-time"`
-	End              int64  `json:"end-time"`
-	Duration         int64  `json:"duration"`
-	LastModification int64  `json:"last-modification"`
-	Tasks            status `json:"tasks"`
+// JobOverview summarizes a job.
+type JobOverview struct {
+	ID               string     `json:"jid"`
+	Name             string     `json:"name"`
+	State            JobStatus  `json:"state"`
+	Start            Timestamp  `json:"start-time"`
+	End              Timestamp  `json:"end-time"`
+	Duration         Duration   `json:"duration"`
+	LastModification Timestamp  `json:"last-modification"`
+	Tasks            TaskCounts `json:"tasks"`
 }
 
-type status struct {
-	Total       int `json:"total,omitempty"`
-	Created     int `json:"created"`
-	Scheduled   int `json:"scheduled"`
-	Deploying   int `json:"deploying"`
-	Running     int `json:"running"`
-	Finished    int `json:"finished"`
-	Canceling   int `json:"canceling"`
-	Canceled    int `json:"canceled"`
-	Failed      int `json:"failed"`
-	Reconciling int `json:"reconciling"`
+// TaskCounts is the number of tasks in each execution
+// state.
+type TaskCounts struct {
+	Total        int `json:"total"`
+	Created      int `json:"created"`
+	Scheduled    int `json:"scheduled"`
+	Deploying    int `json:"deploying"`
+	Running      int `json:"running"`
+	Finished     int `json:"finished"`
+	Canceling    int `json:"canceling"`
+	Canceled     int `json:"canceled"`
+	Failed       int `json:"failed"`
+	Reconciling  int `json:"reconciling"`
+	Initializing int `json:"initializing"`
 }
 
 // JobsOverview returns an overview over all jobs.
-func (c *Client) JobsOverview() (overviewResp, error) {
-	var r overviewResp
-	req, err := http.NewRequest(
+func (c *Client) JobsOverview() (JobsOverviewResp, error) {
+	return c.JobsOverviewContext(context.Background())
+}
+
+// JobsOverviewContext is like JobsOverview but
+// carries a context.
+func (c *Client) JobsOverviewContext(ctx context.Context) (JobsOverviewResp, error) {
+	var r JobsOverviewResp
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url("/jobs/overview"),
 		nil,
@@ -187,53 +372,322 @@ func (c *Client) JobsOverview() (overviewResp, error) {
 	return r, err
 }
 
-type jobResp struct {
-	ID          string `json:"jid"`
-	Name        string `json:"name"`
-	IsStoppable bool   `json:"isStoppable"`
-	State       string `json:"state"`
-
-	Start    int64 `json:"start-time"`
-	End      int64 `json:"end-time"`
-	Duration int64 `json:"duration"`
-	Now      int64 `json:"now"`
-
-	Timestamps   timestamps `json:"timestamps"`
-	Vertices     []vertice  `json:"vertices"`
-	StatusCounts status     `json:"status-counts"`
-	Plan         plan       `json:"plan"`
-}
-
-type timestamps struct {
-	Canceled    int64 `json:"CANCELED"`
-	Suspended   int64 `json:"SUSPENDED"`
-	Finished    int64 `json:"FINISHED"`
-	Canceling   int64 `json:"CANCELLING"`
-	Running     int64 `json:"RUNNING"`
-	Restaring   int64 `json:"RESTARTING"`
-	Reconciling int64 `json:"RECONCILING"`
-	Created     int64 `json:"CREATED"`
-	Failed      int64 `json:"FAILED"`
-	Failing     int64 `json:"FAILING"`
-}
-
-type vertice struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Status      string                 `json:"status"`
-	Parallelism int                    `json:"parallelism"`
-	Start       int64                  `json:"start-time"`
-	End         int64                  `json:"end-time"`
-	Duration    int64                  `json:"duration"`
-	Tasks       status                 `json:"tasks"`
-	Metrics     map[string]interface{} `json:"metrics"`
+// JobResp is the answer of Job.
+type JobResp struct {
+	ID          string    `json:"jid"`
+	Name        string    `json:"name"`
+	IsStoppable bool      `json:"isStoppable"`
+	State       JobStatus `json:"state"`
+
+	Start    Timestamp `json:"start-time"`
+	End      Timestamp `json:"end-time"`
+	Duration Duration  `json:"duration"`
+	Now      Timestamp `json:"now"`
+
+	Timestamps   Timestamps `json:"timestamps"`
+	Vertices     []Vertex   `json:"vertices"`
+	StatusCounts TaskCounts `json:"status-counts"`
+	Plan         Plan       `json:"plan"`
+}
+
+// Timestamps holds the time a job entered each status,
+// zero if it never did.
+type Timestamps struct {
+	Initializing Timestamp `json:"INITIALIZING"`
+	Canceled     Timestamp `json:"CANCELED"`
+	Suspended    Timestamp `json:"SUSPENDED"`
+	Finished     Timestamp `json:"FINISHED"`
+	Canceling    Timestamp `json:"CANCELLING"`
+	Running      Timestamp `json:"RUNNING"`
+	Restarting   Timestamp `json:"RESTARTING"`
+	Reconciling  Timestamp `json:"RECONCILING"`
+	Created      Timestamp `json:"CREATED"`
+	Failed       Timestamp `json:"FAILED"`
+	Failing      Timestamp `json:"FAILING"`
+}
+
+// Get returns the time the job entered status s, a zero
+// Timestamp if it never did or s is unknown.
+func (t Timestamps) Get(s JobStatus) Timestamp {
+	switch s {
+	case JobStatusInitializing:
+		return t.Initializing
+	case JobStatusCreated:
+		return t.Created
+	case JobStatusRunning:
+		return t.Running
+	case JobStatusFailing:
+		return t.Failing
+	case JobStatusFailed:
+		return t.Failed
+	case JobStatusCancelling:
+		return t.Canceling
+	case JobStatusCanceled:
+		return t.Canceled
+	case JobStatusFinished:
+		return t.Finished
+	case JobStatusRestarting:
+		return t.Restarting
+	case JobStatusSuspended:
+		return t.Suspended
+	case JobStatusReconciling:
+		return t.Reconciling
+	}
+	return 0
+}
+
+// Vertex summarizes a job vertex, i.e. a chain of
+// operators.
+type Vertex struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Status         ExecutionState `json:"status"`
+	Parallelism    int            `json:"parallelism"`
+	MaxParallelism int            `json:"maxParallelism"`
+	Start          Timestamp      `json:"start-time"`
+	End            Timestamp      `json:"end-time"`
+	Duration       Duration       `json:"duration"`
+	Tasks          TaskCounts     `json:"tasks"`
+	Metrics        VertexMetrics  `json:"metrics"`
+}
+
+// VertexMetrics holds the I/O metrics of a vertex or
+// subtask. The *Complete fields tell whether all subtasks
+// have reported the matching value.
+type VertexMetrics struct {
+	ReadBytes            int64 `json:"read-bytes"`
+	ReadBytesComplete    bool  `json:"read-bytes-complete"`
+	WriteBytes           int64 `json:"write-bytes"`
+	WriteBytesComplete   bool  `json:"write-bytes-complete"`
+	ReadRecords          int64 `json:"read-records"`
+	ReadRecordsComplete  bool  `json:"read-records-complete"`
+	WriteRecords         int64 `json:"write-records"`
+	WriteRecordsComplete bool  `json:"write-records-complete"`
+
+	AccumulatedBackpressuredTime Duration `json:"accumulated-backpressured-time"`
+	AccumulatedIdleTime          Duration `json:"accumulated-idle-time"`
+	AccumulatedBusyTime          float64  `json:"accumulated-busy-time"`
 }
 
 // Job returns details of a job.
-func (c *Client) Job(jobID string) (jobResp, error) {
-	var r jobResp
+func (c *Client) Job(jobID string) (JobResp, error) {
+	return c.JobContext(context.Background(), jobID)
+}
+
+// JobContext is like Job but carries a context.
+func (c *Client) JobContext(ctx context.Context, jobID string) (JobResp, error) {
+	var r JobResp
 	uri := fmt.Sprintf("/jobs/%s", jobID)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// JobConfigResp is the answer of JobConfig.
+type JobConfigResp struct {
+	ID              string          `json:"jid"`
+	Name            string          `json:"name"`
+	ExecutionConfig ExecutionConfig `json:"execution-config"`
+}
+
+// ExecutionConfig is the execution configuration of a job.
+// UserConfig holds the global job parameters set by user
+// code.
+type ExecutionConfig struct {
+	ExecutionMode   string            `json:"execution-mode"`
+	RestartStrategy string            `json:"restart-strategy"`
+	JobParallelism  int               `json:"job-parallelism"`
+	ObjectReuseMode bool              `json:"object-reuse-mode"`
+	UserConfig      map[string]string `json:"user-config"`
+}
+
+// JobConfig returns the configuration of a job.
+func (c *Client) JobConfig(jobID string) (JobConfigResp, error) {
+	return c.JobConfigContext(context.Background(), jobID)
+}
+
+// JobConfigContext is like JobConfig but carries a context.
+func (c *Client) JobConfigContext(ctx context.Context, jobID string) (JobConfigResp, error) {
+	var r JobConfigResp
+	uri := fmt.Sprintf("/jobs/%s/config", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// JobExceptionsResp is the answer of JobExceptions. The
+// root and all-exceptions fields are deprecated by Flink
+// in favor of ExceptionHistory.
+type JobExceptionsResp struct {
+	RootException    string             `json:"root-exception"`
+	Timestamp        Timestamp          `json:"timestamp"`
+	AllExceptions    []ExecutionFailure `json:"all-exceptions"`
+	Truncated        bool               `json:"truncated"`
+	ExceptionHistory ExceptionHistory   `json:"exceptionHistory"`
+}
+
+// ExecutionFailure is the failure of a task.
+type ExecutionFailure struct {
+	Exception     string    `json:"exception"`
+	Task          string    `json:"task"`
+	Location      string    `json:"location"`
+	Timestamp     Timestamp `json:"timestamp"`
+	TaskManagerID string    `json:"taskManagerId,omitempty"`
+}
+
+// ExceptionHistory lists the failures of a job, the most
+// recent first.
+type ExceptionHistory struct {
+	Entries   []RootException `json:"entries"`
+	Truncated bool            `json:"truncated"`
+}
+
+// RootException is a failure that caused the job to
+// restart or fail, along with the failures that happened
+// at the same time.
+type RootException struct {
+	ExceptionEntry
+	ConcurrentExceptions []ExceptionEntry `json:"concurrentExceptions"`
+}
+
+// ExceptionEntry is a failure of the job. TaskName,
+// Location and TaskManagerID are empty for failures that
+// are not bound to a task.
+type ExceptionEntry struct {
+	ExceptionName string            `json:"exceptionName"`
+	Stacktrace    string            `json:"stacktrace"`
+	Timestamp     Timestamp         `json:"timestamp"`
+	TaskName      string            `json:"taskName,omitempty"`
+	Location      string            `json:"location,omitempty"`
+	Endpoint      string            `json:"endpoint,omitempty"`
+	TaskManagerID string            `json:"taskManagerId,omitempty"`
+	FailureLabels map[string]string `json:"failureLabels"`
+}
+
+// JobExceptions returns the failures of a job. maxExceptions
+// limits the number of returned entries, 0 uses the server
+// default.
+func (c *Client) JobExceptions(jobID string, maxExceptions int) (JobExceptionsResp, error) {
+	return c.JobExceptionsContext(context.Background(), jobID, maxExceptions)
+}
+
+// JobExceptionsContext is like JobExceptions but carries a
+// context.
+func (c *Client) JobExceptionsContext(ctx context.Context, jobID string, maxExceptions int) (JobExceptionsResp, error) {
+	var r JobExceptionsResp
+	uri := fmt.Sprintf("/jobs/%s/exceptions", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	if maxExceptions > 0 {
+		q := req.URL.Query()
+		q.Add("maxExceptions", strconv.Itoa(maxExceptions))
+		req.URL.RawQuery = q.Encode()
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// ExecutionResultResp is the answer of JobExecutionResult.
+// JobExecutionResult is nil until the job reached a
+// globally terminal status.
+type ExecutionResultResp struct {
+	Status             QueueStatus         `json:"status"`
+	JobExecutionResult *JobExecutionResult `json:"job-execution-result"`
+}
+
+// Application statuses of a finished job.
+const (
+	ApplicationSucceeded = "SUCCEEDED"
+	ApplicationFailed    = "FAILED"
+	ApplicationCanceled  = "CANCELED"
+	ApplicationUnknown   = "UNKNOWN"
+)
+
+// JobExecutionResult is the outcome of a finished job.
+// AccumulatorResults holds the serialized accumulator
+// values and FailureCause is set when the job failed.
+type JobExecutionResult struct {
+	ID                 string            `json:"id"`
+	ApplicationStatus  string            `json:"application-status"`
+	AccumulatorResults map[string]string `json:"accumulator-results"`
+	NetRuntime         Duration          `json:"net-runtime"`
+	FailureCause       *FailureCause     `json:"failure-cause,omitempty"`
+}
+
+// JobExecutionResult returns the result of a job once it
+// finished, failed or was canceled.
+func (c *Client) JobExecutionResult(jobID string) (ExecutionResultResp, error) {
+	return c.JobExecutionResultContext(context.Background(), jobID)
+}
+
+// JobExecutionResultContext is like JobExecutionResult but
+// carries a context.
+func (c *Client) JobExecutionResultContext(ctx context.Context, jobID string) (ExecutionResultResp, error) {
+	var r ExecutionResultResp
+	uri := fmt.Sprintf("/jobs/%s/execution-result", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// JobPlan returns the dataflow plan of a job.
+func (c *Client) JobPlan(jobID string) (PlanResp, error) {
+	return c.JobPlanContext(context.Background(), jobID)
+}
+
+// JobPlanContext is like JobPlan but carries a context.
+func (c *Client) JobPlanContext(ctx context.Context, jobID string) (PlanResp, error) {
+	var r PlanResp
+	uri := fmt.Sprintf("/jobs/%s/plan", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url(uri),
 		nil,
@@ -249,10 +703,44 @@ func (c *Client) Job(jobID string) (jobResp, error) {
 	return r, err
 }
 
+// JobStatus returns the current status of a job.
+func (c *Client) JobStatus(jobID string) (JobStatus, error) {
+	return c.JobStatusContext(context.Background(), jobID)
+}
+
+// JobStatusContext is like JobStatus but carries a context.
+func (c *Client) JobStatusContext(ctx context.Context, jobID string) (JobStatus, error) {
+	var r struct {
+		Status JobStatus `json:"status"`
+	}
+	uri := fmt.Sprintf("/jobs/%s/status", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r.Status, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r.Status, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r.Status, err
+}
+
 // StopJob terminates a job.
 func (c *Client) StopJob(jobID string) error {
+	return c.StopJobContext(context.Background(), jobID)
+}
+
+// StopJobContext is like StopJob but carries a context.
+func (c *Client) StopJobContext(ctx context.Context, jobID string) error {
 	uri := fmt.Sprintf("/jobs/%s", jobID)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"PATCH",
 		c.url(uri),
 		nil,
@@ -264,14 +752,16 @@ func (c *Client) StopJob(jobID string) error {
 	return err
 }
 
-type checkpointsResp struct {
-	Counts  counts                     `json:"counts"`
-	Summary summary                    `json:"summary"`
-	Latest  latest                     `json:"latest"`
-	History []failedCheckpointsStatics `json:"history"`
+// CheckpointsResp is the answer of Checkpoints.
+type CheckpointsResp struct {
+	Counts  CheckpointCounts       `json:"counts"`
+	Summary CheckpointSummary      `json:"summary"`
+	Latest  LatestCheckpoints      `json:"latest"`
+	History []CheckpointStatistics `json:"history"`
 }
 
-type counts struct {
+// CheckpointCounts is the number of checkpoints by status.
+type CheckpointCounts struct {
 	Restored   int `json:"restored"`
 	Total      int `json:"total"`
 	InProgress int `json:"in_progress"`
@@ -279,98 +769,110 @@ type counts struct {
 	Failed     int `json:"failed"`
 }
 
-type summary struct {
-	StateSize         statics `json:"state_size`
-	End2EndDuration   statics `json:"end_to_end_duration"`
-	AlignmentBuffered statics `json:"alignment_buffered"`
-}
-
-type statics struct {
-	Min int `json:"min"`
-	Max int `json:"max"`
-	Avg int `json:"avg"`
-}
-
-type latest struct {
-	Completed completedCheckpointsStatics `json:"completed"`
-	Savepoint savepointsStatics           `json:"savepoint"`
-	Failed    failedCheckpointsStatics    `json:"failed"`
-	Restored  restoredCheckpointsStatics  `json:"restored"`
-}
-
-type completedCheckpointsStatics struct {
-	ID                      string                 `json:"id"`
-	Status                  string                 `json:"status"`
-	IsSavepoint             bool                   `json:"is_savepoint"`
-	TriggerTimestamp        int64                  `json:"trigger_timestamp"`
-	LatestAckTimestamp      int64                  `json:"latest_ack_timestamp"`
-	StateSize               int64                  `json:"state_size"`
-	End2EndDuration         int64                  `json:"end_to_end_duration"`
-	AlignmentBuffered       int64                  `json:"alignment_buffered"`
-	NumSubtasks             int64                  `json:"num_subtasks"`
-	NumAcknowledgedSubtasks int64                  `json:"num_acknowledged_subtasks"`
-	tasks                   taskCheckpointsStatics `json:"tasks"`
-	ExternalPath            string                 `json:"external_path"`
-	Discarded               bool                   `json:"discarded"`
-}
-
-type savepointsStatics struct {
-	ID                      int                    `json:"id"`
-	Status                  string                 `json:"status"`
-	IsSavepoint             bool                   `json:"is_savepoint"`
-	TriggerTimestamp        int64                  `json:"trigger_timestamp"`
-	LatestAckTimestamp      int64                  `json:"latest_ack_timestamp"`
-	StateSize               int64                  `json:"state_size"`
-	End2EndDuration         int64                  `json:"end_to_end_duration"`
-	AlignmentBuffered       int64                  `json:"alignment_buffered"`
-	NumSubtasks             int64                  `json:"num_subtasks"`
-	NumAcknowledgedSubtasks int64                  `json:"num_acknowledged_subtasks"`
-	tasks                   taskCheckpointsStatics `json:"tasks"`
-	ExternalPath            string                 `json:"external_path"`
-	Discarded               bool                   `json:"discarded"`
-}
-type taskCheckpointsStatics struct {
-	ID     string `json:"id"`
+// CheckpointSummary aggregates the completed checkpoints.
+// Sizes are in bytes and durations in milliseconds.
+type CheckpointSummary struct {
+	StateSize         MinMaxAvg `json:"state_size"`
+	End2EndDuration   MinMaxAvg `json:"end_to_end_duration"`
+	AlignmentBuffered MinMaxAvg `json:"alignment_buffered"`
+	ProcessedData     MinMaxAvg `json:"processed_data"`
+	PersistedData     MinMaxAvg `json:"persisted_data"`
+}
+
+// MinMaxAvg is a statistic over several values. Flink
+// versions before 1.13 do not report the percentiles.
+type MinMaxAvg struct {
+	Min  int64   `json:"min"`
+	Max  int64   `json:"max"`
+	Avg  int64   `json:"avg"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p999"`
+}
+
+// LatestCheckpoints holds the most recent checkpoint of
+// each kind, nil if there is none.
+type LatestCheckpoints struct {
+	Completed *CheckpointStatistics         `json:"completed"`
+	Savepoint *CheckpointStatistics         `json:"savepoint"`
+	Failed    *CheckpointStatistics         `json:"failed"`
+	Restored  *RestoredCheckpointStatistics `json:"restored"`
+}
+
+// CheckpointStatistics describes a checkpoint or savepoint.
+// ExternalPath and Discarded are only set for completed
+// checkpoints, FailureTimestamp and FailureMessage for
+// failed ones.
+type CheckpointStatistics struct {
+	// Class is "completed", "failed" or "in_progress".
+	Class                   string                              `json:"className"`
+	ID                      int64                               `json:"id"`
+	Status                  string                              `json:"status"`
+	IsSavepoint             bool                                `json:"is_savepoint"`
+	CheckpointType          string                              `json:"checkpoint_type,omitempty"`
+	TriggerTimestamp        Timestamp                           `json:"trigger_timestamp"`
+	LatestAckTimestamp      Timestamp                           `json:"latest_ack_timestamp"`
+	CheckpointedSize        int64                               `json:"checkpointed_size"`
+	StateSize               int64                               `json:"state_size"`
+	End2EndDuration         Duration                            `json:"end_to_end_duration"`
+	AlignmentBuffered       int64                               `json:"alignment_buffered"`
+	ProcessedData           int64                               `json:"processed_data"`
+	PersistedData           int64                               `json:"persisted_data"`
+	NumSubtasks             int64                               `json:"num_subtasks"`
+	NumAcknowledgedSubtasks int64                               `json:"num_acknowledged_subtasks"`
+	Tasks                   map[string]TaskCheckpointStatistics `json:"tasks"`
+
+	ExternalPath string `json:"external_path,omitempty"`
+	Discarded    bool   `json:"discarded"`
+
+	FailureTimestamp Timestamp `json:"failure_timestamp,omitempty"`
+	FailureMessage   string    `json:"failure_message,omitempty"`
+}
+
+// TaskCheckpointStatistics describes a checkpoint of a
+// single job vertex.
+type TaskCheckpointStatistics struct {
+	ID     int64  `json:"id"`
 	Status string `json:"status"`
 
-	LatestAckTimestamp int64 `json:"latest_ack_timestamp"`
+	LatestAckTimestamp Timestamp `json:"latest_ack_timestamp"`
 
-	FailureTimestamp int64  `json:"failure_timestamp"`
-	FailureMessage   string `json:"failure_message"`
+	FailureTimestamp Timestamp `json:"failure_timestamp,omitempty"`
+	FailureMessage   string    `json:"failure_message,omitempty"`
 
-	StateSize               int64 `json:"state_size"`
-	End2EndDuration         int64 `json:"end_to_end_duration"`
-	AlignmentBuffered       int64 `json:"alignment_buffered"`
-	NumSubtasks             int64 `json:"num_subtasks"`
-	NumAcknowledgedSubtasks int64 `json:"num_acknowledged_subtasks"`
+	CheckpointedSize        int64    `json:"checkpointed_size"`
+	StateSize               int64    `json:"state_size"`
+	End2EndDuration         Duration `json:"end_to_end_duration"`
+	AlignmentBuffered       int64    `json:"alignment_buffered"`
+	ProcessedData           int64    `json:"processed_data"`
+	PersistedData           int64    `json:"persisted_data"`
+	NumSubtasks             int64    `json:"num_subtasks"`
+	NumAcknowledgedSubtasks int64    `json:"num_acknowledged_subtasks"`
 }
 
-type failedCheckpointsStatics struct {
-	ID                      int64                  `json:"id"`
-	Status                  string                 `json:"status"`
-	IsSavepoint             bool                   `json:"is_savepoint"`
-	TriggerTimestamp        int64                  `json:"trigger_timestamp"`
-	LatestAckTimestamp      int64                  `json:"latest_ack_timestamp"`
-	StateSize               int64                  `json:"state_size"`
-	End2EndDuration         int64                  `json:"end_to_end_duration"`
-	AlignmentBuffered       int64                  `json:"alignment_buffered"`
-	NumSubtasks             int64                  `json:"num_subtasks"`
-	NumAcknowledgedSubtasks int64                  `json:"num_acknowledged_subtasks"`
-	tasks                   taskCheckpointsStatics `json:"tasks"`
+// RestoredCheckpointStatistics describes the checkpoint a
+// job was restored from.
+type RestoredCheckpointStatistics struct {
+	ID               int64     `json:"id"`
+	RestoreTimestamp Timestamp `json:"restore_timestamp"`
+	IsSavepoint      bool      `json:"is_savepoint"`
+	ExternalPath     string    `json:"external_path"`
 }
 
-type restoredCheckpointsStatics struct {
-	ID               int64  `json:"id"`
-	RestoreTimestamp int64  `json:"restore_timestamp"`
-	IsSavepoint      bool   `json:"is_savepoint"`
-	ExternalPath     string `json:"external_path"`
+// Checkpoints returns checkpointing statistics for a job.
+func (c *Client) Checkpoints(jobID string) (CheckpointsResp, error) {
+	return c.CheckpointsContext(context.Background(), jobID)
 }
 
-// Checkpoints returns checkpointing statistics for a job.
-func (c *Client) Checkpoints(jobID string) (checkpointsResp, error) {
-	var r checkpointsResp
+// CheckpointsContext is like Checkpoints but
+// carries a context.
+func (c *Client) CheckpointsContext(ctx context.Context, jobID string) (CheckpointsResp, error) {
+	var r CheckpointsResp
 	uri := fmt.Sprintf("/jobs/%s/checkpoints", jobID)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.url(uri),
 		nil,
@@ -386,15 +888,30 @@ func (c *Client) Checkpoints(jobID string) (checkpointsResp, error) {
 	return r, err
 }
 
-type savePointsResp struct {
+// SavePointsResp is the answer of SavePoints.
+type SavePointsResp struct {
 	RequestID string `json:"request-id"`
+
+	op *Operation
+}
+
+// Operation returns a handle on the triggered savepoint.
+func (r SavePointsResp) Operation() *Operation {
+	return r.op
 }
 
 // SavePoints triggers a savepoint, and optionally cancels the
 // job afterwards. This async operation would return a
-// 'triggerid' for further query identifier.
-func (c *Client) SavePoints(jobID string, saveDir string, cancleJob bool) (savePointsResp, error) {
-	var r savePointsResp
+// 'triggerid' for further query identifier, see
+// SavePointsResp.Operation.
+func (c *Client) SavePoints(jobID string, saveDir string, cancleJob bool) (SavePointsResp, error) {
+	return c.SavePointsContext(context.Background(), jobID, saveDir, cancleJob)
+}
+
+// SavePointsContext is like SavePoints but
+// carries a context.
+func (c *Client) SavePointsContext(ctx context.Context, jobID string, saveDir string, cancleJob bool) (SavePointsResp, error) {
+	var r SavePointsResp
 
 	type savePointsReq struct {
 		SaveDir   string `json:"target-directory"`
@@ -408,7 +925,8 @@ func (c *Client) SavePoints(jobID string, saveDir string, cancleJob bool) (saveP
 	data := new(bytes.Buffer)
 	json.NewEncoder(data).Encode(d)
 	uri := fmt.Sprintf("/jobs/%s/savepoints", jobID)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		c.url(uri),
 		data,
@@ -420,20 +938,39 @@ func (c *Client) SavePoints(jobID string, saveDir string, cancleJob bool) (saveP
 	if err != nil {
 		return r, err
 	}
-	err = json.Unmarshal(b, &r)
-	return r, err
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, err
+	}
+	r.op = c.SavepointOperation(jobID, r.RequestID)
+	return r, nil
 }
 
-type stopJobResp struct {
+// StopJobResp is the answer of StopJobWithSavepoint.
+type StopJobResp struct {
 	RequestID string `json:"request-id"`
+
+	op *Operation
+}
+
+// Operation returns a handle on the savepoint taken before
+// the job stops.
+func (r StopJobResp) Operation() *Operation {
+	return r.op
 }
 
 // StopJob stops a job with a savepoint. Optionally, it can also
 // emit a MAX_WATERMARK before taking the savepoint to flush out
 // any state waiting for timers to fire. This async operation
-// would return a 'triggerid' for further query identifier.
-func (c *Client) StopJobWithSavepoint(jobID string, saveDir string, drain bool) (stopJobResp, error) {
-	var r stopJobResp
+// would return a 'triggerid' for further query identifier,
+// see StopJobResp.Operation.
+func (c *Client) StopJobWithSavepoint(jobID string, saveDir string, drain bool) (StopJobResp, error) {
+	return c.StopJobWithSavepointContext(context.Background(), jobID, saveDir, drain)
+}
+
+// StopJobWithSavepointContext is like StopJobWithSavepoint but
+// carries a context.
+func (c *Client) StopJobWithSavepointContext(ctx context.Context, jobID string, saveDir string, drain bool) (StopJobResp, error) {
+	var r StopJobResp
 	type stopJobReq struct {
 		SaveDir string `json:"targetDirectory"`
 		Drain   bool   `json:"drain"`
@@ -446,7 +983,8 @@ func (c *Client) StopJobWithSavepoint(jobID string, saveDir string, drain bool)
 	data := new(bytes.Buffer)
 	json.NewEncoder(data).Encode(d)
 	uri := fmt.Sprintf("/jobs/%s/stop", jobID)
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		c.url(uri),
 		data,
@@ -458,6 +996,9 @@ func (c *Client) StopJobWithSavepoint(jobID string, saveDir string, drain bool)
 	if err != nil {
 		return r, err
 	}
-	err = json.Unmarshal(b, &r)
-	return r, err
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, err
+	}
+	r.op = c.SavepointOperation(jobID, r.RequestID)
+	return r, nil
 }
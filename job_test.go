@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSubmitJobStreamsFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"job.graph": "serialized job graph",
+		"udf.jar":   "jar content",
+		"dict.txt":  "cached file",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// the first attempt fails to check the body is
+			// sent again in full
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method != "POST" || r.URL.Path != "/jobs" {
+			t.Errorf("got %s %s, want POST /jobs", r.Method, r.URL.Path)
+		}
+		if r.ContentLength <= 0 {
+			t.Errorf("Content-Length = %d, want the size of the body", r.ContentLength)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		var req struct {
+			JobGraphFileName     string   `json:"jobGraphFileName"`
+			JobJarFileNames      []string `json:"jobJarFileNames"`
+			JobArtifactFileNames []struct {
+				EntryName string `json:"entryName"`
+				FileName  string `json:"fileName"`
+			} `json:"jobArtifactFileNames"`
+		}
+		if err := json.Unmarshal([]byte(r.FormValue("request")), &req); err != nil {
+			t.Error(err)
+		}
+		if req.JobGraphFileName != "job.graph" || len(req.JobJarFileNames) != 1 || req.JobJarFileNames[0] != "udf.jar" ||
+			len(req.JobArtifactFileNames) != 1 || req.JobArtifactFileNames[0].EntryName != "dict" || req.JobArtifactFileNames[0].FileName != "dict.txt" {
+			t.Errorf("request = %+v", req)
+		}
+		for _, fhs := range r.MultipartForm.File {
+			for _, fh := range fhs {
+				f, err := fh.Open()
+				if err != nil {
+					t.Error(err)
+					continue
+				}
+				b, _ := ioutil.ReadAll(f)
+				f.Close()
+				if string(b) != files[fh.Filename] {
+					t.Errorf("file %s = %q, want %q", fh.Filename, b, files[fh.Filename])
+				}
+				delete(files, fh.Filename)
+			}
+		}
+		if len(files) != 0 {
+			t.Errorf("files %v were not sent", files)
+		}
+		w.Write([]byte(`{"jobUrl":"/jobs/` + testJobID + `"}`))
+	}))
+	defer srv.Close()
+
+	p := testRetryPolicy(2)
+	p.RetryNonIdempotent = true
+	c, err := New(srv.URL, WithRetryPolicy(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.SubmitJob(SubmitOpts{
+		JobGraphFile: filepath.Join(dir, "job.graph"),
+		JarFiles:     []string{filepath.Join(dir, "udf.jar")},
+		Artifacts:    []Artifact{{EntryName: "dict", Path: filepath.Join(dir, "dict.txt")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.JobID() != testJobID {
+		t.Errorf("JobID() = %q, want %q", resp.JobID(), testJobID)
+	}
+	if calls != 2 {
+		t.Errorf("server got %d requests, want 2", calls)
+	}
+}
+
+func TestSubmitJobMissingFile(t *testing.T) {
+	c, err := New("localhost:8081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SubmitJob(SubmitOpts{JobGraphFile: filepath.Join(t.TempDir(), "missing.graph")})
+	if !os.IsNotExist(err) {
+		t.Errorf("SubmitJob() error = %v, want a missing file error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// EnvironmentResp is the JVM environment of the job
+// manager.
+type EnvironmentResp struct {
+	JVM       JVMInfo  `json:"jvm"`
+	Classpath []string `json:"classpath"`
+}
+
+// JVMInfo describes a JVM.
+type JVMInfo struct {
+	Version string   `json:"version"`
+	Arch    string   `json:"arch"`
+	Options []string `json:"options"`
+}
+
+// JobManagerEnvironment returns the JVM and classpath of
+// the job manager.
+func (c *Client) JobManagerEnvironment() (EnvironmentResp, error) {
+	return c.JobManagerEnvironmentContext(context.Background())
+}
+
+// JobManagerEnvironmentContext is like
+// JobManagerEnvironment but carries a context.
+func (c *Client) JobManagerEnvironmentContext(ctx context.Context) (EnvironmentResp, error) {
+	var r EnvironmentResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/jobmanager/environment"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// JobManagerLogs lists the log files of the job manager.
+func (c *Client) JobManagerLogs() (LogsResp, error) {
+	return c.JobManagerLogsContext(context.Background())
+}
+
+// JobManagerLogsContext is like JobManagerLogs but carries
+// a context.
+func (c *Client) JobManagerLogsContext(ctx context.Context) (LogsResp, error) {
+	var r LogsResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/jobmanager/logs"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// JobManagerLogFile streams a log file of the job manager
+// listed by JobManagerLogs. The caller must close the
+// returned reader.
+func (c *Client) JobManagerLogFile(name string) (io.ReadCloser, error) {
+	return c.JobManagerLogFileContext(context.Background(), name)
+}
+
+// JobManagerLogFileContext is like JobManagerLogFile but
+// carries a context.
+func (c *Client) JobManagerLogFileContext(ctx context.Context, name string) (io.ReadCloser, error) {
+	uri := fmt.Sprintf("/jobmanager/logs/%s", url.PathEscape(name))
+	return c.stream(ctx, uri)
+}
+
+// JobManagerLog streams the main log file of the job
+// manager. The caller must close the returned reader.
+func (c *Client) JobManagerLog() (io.ReadCloser, error) {
+	return c.JobManagerLogContext(context.Background())
+}
+
+// JobManagerLogContext is like JobManagerLog but carries a
+// context.
+func (c *Client) JobManagerLogContext(ctx context.Context) (io.ReadCloser, error) {
+	return c.stream(ctx, "/jobmanager/log")
+}
+
+// JobManagerStdout streams the stdout file of the job
+// manager. The caller must close the returned reader.
+func (c *Client) JobManagerStdout() (io.ReadCloser, error) {
+	return c.JobManagerStdoutContext(context.Background())
+}
+
+// JobManagerStdoutContext is like JobManagerStdout but
+// carries a context.
+func (c *Client) JobManagerStdoutContext(ctx context.Context) (io.ReadCloser, error) {
+	return c.stream(ctx, "/jobmanager/stdout")
+}
+
+// JobManagerThreadDump returns a thread dump of the job
+// manager.
+func (c *Client) JobManagerThreadDump() (ThreadDumpResp, error) {
+	return c.JobManagerThreadDumpContext(context.Background())
+}
+
+// JobManagerThreadDumpContext is like JobManagerThreadDump
+// but carries a context.
+func (c *Client) JobManagerThreadDumpContext(ctx context.Context) (ThreadDumpResp, error) {
+	var r ThreadDumpResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/jobmanager/thread-dump"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Statuses of an asynchronous operation.
+const (
+	OperationInProgress = "IN_PROGRESS"
+	OperationCompleted  = "COMPLETED"
+)
+
+// defaultPollInterval is used by Operation.Wait when no
+// positive interval is given.
+const defaultPollInterval = time.Second
+
+// Operation is a handle on an asynchronous operation, such
+// as a savepoint, started by a trigger call. Flink answers
+// the trigger with a request ID, which is the TriggerID
+// here, and reports the progress on a status resource.
+type Operation struct {
+	// TriggerID is the 'request-id' returned by the
+	// trigger call.
+	TriggerID string
+
+	client     *Client
+	statusPath string
+}
+
+// TriggerResp is the answer of a call starting an
+// asynchronous operation.
+type TriggerResp struct {
+	RequestID string `json:"request-id"`
+}
+
+// OperationStatus is the answer of Operation.Status.
+// Operation is nil while the operation is in progress.
+type OperationStatus struct {
+	Status    QueueStatus      `json:"status"`
+	Operation *OperationResult `json:"operation"`
+}
+
+// QueueStatus holds the status ID of an operation,
+// OperationInProgress or OperationCompleted.
+type QueueStatus struct {
+	ID string `json:"id"`
+}
+
+// Done reports whether the operation completed, either
+// successfully or with a failure.
+func (s OperationStatus) Done() bool {
+	return s.Status.ID == OperationCompleted
+}
+
+// OperationResult is the outcome of a completed operation.
+// Location is the path of a written savepoint, and
+// CheckpointID the ID of a manually triggered checkpoint.
+// FailureCause is set when the operation failed.
+type OperationResult struct {
+	Location     string        `json:"location,omitempty"`
+	CheckpointID int64         `json:"checkpointId,omitempty"`
+	FailureCause *FailureCause `json:"failure-cause,omitempty"`
+}
+
+// FailureCause is a serialized Java exception.
+type FailureCause struct {
+	Class               string `json:"class"`
+	StackTrace          string `json:"stack-trace"`
+	SerializedThrowable string `json:"serialized-throwable"`
+}
+
+// Message returns the first line of the stack trace, which
+// holds the exception class and message.
+func (f FailureCause) Message() string {
+	msg := f.StackTrace
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		msg = f.Class
+	}
+	return msg
+}
+
+// OperationError is returned by Operation.Wait when the
+// operation completed with a failure.
+type OperationError struct {
+	TriggerID string
+	Cause     FailureCause
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %s failed: %s", e.TriggerID, e.Cause.Message())
+}
+
+// newOperation returns a handle on the operation whose
+// status is served at statusPath.
+func (c *Client) newOperation(triggerID, statusPath string) *Operation {
+	return &Operation{
+		TriggerID:  triggerID,
+		client:     c,
+		statusPath: statusPath,
+	}
+}
+
+// SavepointOperation returns a handle on the savepoint
+// triggered by SavePoints or StopJobWithSavepoint, given
+// the returned request ID. The answers of both calls also
+// carry the handle, see SavePointsResp.Operation.
+func (c *Client) SavepointOperation(jobID, triggerID string) *Operation {
+	uri := fmt.Sprintf("/jobs/%s/savepoints/%s", jobID, triggerID)
+	return c.newOperation(triggerID, uri)
+}
+
+// Status returns the current status of the operation.
+func (o *Operation) Status() (OperationStatus, error) {
+	return o.StatusContext(context.Background())
+}
+
+// StatusContext is like Status but carries a context.
+func (o *Operation) StatusContext(ctx context.Context) (OperationStatus, error) {
+	var r OperationStatus
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		o.client.url(o.statusPath),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := o.client.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// Wait polls the status of the operation every
+// pollInterval until it completes or ctx is done. A failed
+// operation is returned as an *OperationError.
+func (o *Operation) Wait(ctx context.Context, pollInterval time.Duration) (OperationResult, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	for {
+		s, err := o.StatusContext(ctx)
+		if err != nil {
+			return OperationResult{}, err
+		}
+		if s.Done() {
+			var r OperationResult
+			if s.Operation != nil {
+				r = *s.Operation
+			}
+			if r.FailureCause != nil {
+				return r, &OperationError{TriggerID: o.TriggerID, Cause: *r.FailureCause}
+			}
+			return r, nil
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return OperationResult{}, err
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSavepointTriggerOperation(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/"+testJobID+"/savepoints", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request-id":"sp-1"}`))
+	})
+	mux.HandleFunc("/jobs/"+testJobID+"/stop", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"request-id":"sp-1"}`))
+	})
+	mux.HandleFunc("/jobs/"+testJobID+"/savepoints/sp-1", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) == 1 {
+			w.Write([]byte(`{"status":{"id":"IN_PROGRESS"},"operation":null}`))
+			return
+		}
+		w.Write([]byte(`{"status":{"id":"COMPLETED"},"operation":{"location":"file:/savepoints/sp-1"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	c, err := New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp, err := c.SavePoints(testJobID, "file:/savepoints", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop, err := c.StopJobWithSavepoint(testJobID, "file:/savepoints", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []*Operation{sp.Operation(), stop.Operation()} {
+		atomic.StoreInt32(&polls, 0)
+		if op == nil || op.TriggerID != "sp-1" {
+			t.Fatalf("Operation() = %+v, want trigger sp-1", op)
+		}
+		res, err := op.Wait(context.Background(), time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Location != "file:/savepoints/sp-1" || polls != 2 {
+			t.Errorf("Wait() = %+v after %d polls", res, polls)
+		}
+	}
+}
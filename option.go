@@ -0,0 +1,94 @@
+package api
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option configures a Client created by New.
+type Option func(*options) error
+
+type options struct {
+	httpClient *http.Client
+	timeout    time.Duration
+	basePath   string
+	userAgent  string
+	headers    http.Header
+	retry      *RetryPolicy
+
+	tlsConfig  *tls.Config
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+
+	endpointHook func(*http.Request, string)
+	auth         Authenticator
+}
+
+// WithHTTPClient makes the client send requests through hc
+// instead of a zero-value http.Client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *options) error {
+		if hc == nil {
+			return fmt.Errorf("http client is nil")
+		}
+		o.httpClient = hc
+		return nil
+	}
+}
+
+// WithTimeout sets a time limit for every request made by
+// the client, including reading the response body. Streamed
+// log files are only limited until the response arrives, so
+// that large files are not cut off; use a context to bound
+// reading them.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d < 0 {
+			return fmt.Errorf("negative timeout: %s", d)
+		}
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithBasePath prefixes every request path, e.g. "/flink"
+// when the web UI is served behind an ingress sub path.
+func WithBasePath(p string) Option {
+	return func(o *options) error {
+		if strings.ContainsAny(p, "?#") {
+			return fmt.Errorf("invalid base path %q", p)
+		}
+		o.basePath = p
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(o *options) error {
+		o.userAgent = ua
+		return nil
+	}
+}
+
+// WithDefaultHeaders adds h to every request. Headers set
+// by the client itself, such as Content-Type, take
+// precedence.
+func WithDefaultHeaders(h http.Header) Option {
+	return func(o *options) error {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		for k, vs := range h {
+			for _, v := range vs {
+				o.headers.Add(k, v)
+			}
+		}
+		return nil
+	}
+}
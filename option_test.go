@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		opts    []Option
+		want    string
+		wantErr bool
+	}{
+		{name: "host and port", addr: "jobmanager:8081", want: "http://jobmanager:8081"},
+		{name: "ip", addr: "10.0.0.12:8081", want: "http://10.0.0.12:8081"},
+		{name: "ipv6", addr: "[::1]:8081", want: "http://[::1]:8081"},
+		{name: "tls defaults to https", addr: "jobmanager:8081", opts: []Option{WithServerName("jobmanager")}, want: "https://jobmanager:8081"},
+		{name: "explicit scheme", addr: "https://flink.example.com", want: "https://flink.example.com"},
+		{name: "trailing slash", addr: "http://jobmanager:8081/", want: "http://jobmanager:8081"},
+		{name: "trailing slashes", addr: "http://flink.example.com/flink///", want: "http://flink.example.com/flink"},
+		{name: "base path", addr: "jobmanager:8081", opts: []Option{WithBasePath("/flink")}, want: "http://jobmanager:8081/flink"},
+		{name: "base path without leading slash", addr: "jobmanager:8081", opts: []Option{WithBasePath("flink/")}, want: "http://jobmanager:8081/flink"},
+		{name: "base path after address path", addr: "https://example.com/proxy/", opts: []Option{WithBasePath("flink")}, want: "https://example.com/proxy/flink"},
+		{name: "base path root", addr: "jobmanager:8081", opts: []Option{WithBasePath("/")}, want: "http://jobmanager:8081"},
+
+		{name: "empty", addr: "", wantErr: true},
+		{name: "unsupported scheme", addr: "ftp://jobmanager:8081", wantErr: true},
+		{name: "missing host", addr: "http://", wantErr: true},
+		{name: "missing host with path", addr: "http:///flink", wantErr: true},
+		{name: "bad port", addr: "jobmanager:port", wantErr: true},
+		{name: "unclosed ipv6", addr: "[::1:8081", wantErr: true},
+		{name: "space in host", addr: "job manager:8081", wantErr: true},
+		{name: "query", addr: "jobmanager:8081?x=1", wantErr: true},
+		{name: "fragment", addr: "http://jobmanager:8081/#ui", wantErr: true},
+		{name: "base path with query", addr: "jobmanager:8081", opts: []Option{WithBasePath("/flink?x=1")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.addr, tt.opts...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("New(%q) = %s, want an error", tt.addr, c.Endpoint())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New(%q) error = %v", tt.addr, err)
+			}
+			if c.Endpoint() != tt.want {
+				t.Errorf("New(%q).Endpoint() = %s, want %s", tt.addr, c.Endpoint(), tt.want)
+			}
+			if got, want := c.url("/jobs"), tt.want+"/jobs"; got != want {
+				t.Errorf("url(/jobs) = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	srv := newScriptedServer(t, runOK)
+	c, err := New(srv.URL,
+		WithDefaultHeaders(http.Header{
+			"X-Team":       {"data"},
+			"X-Trace":      {"a", "b"},
+			"Content-Type": {"text/plain"},
+			"User-Agent":   {"default"},
+		}),
+		WithUserAgent("flink-go-test"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.RunJar(RunOpts{JarID: testJarID}); err != nil {
+		t.Fatal(err)
+	}
+
+	h := srv.headers[0]
+	for k, want := range map[string][]string{
+		"X-Team":       {"data"},
+		"X-Trace":      {"a", "b"},
+		"Content-Type": {"application/json"},
+		"User-Agent":   {"flink-go-test"},
+	} {
+		if got := h[k]; !reflect.DeepEqual(got, want) {
+			t.Errorf("header %s = %q, want %q", k, got, want)
+		}
+	}
+}
@@ -1,34 +1,269 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 type httpClient struct {
-	client http.Client
+	client *http.Client
+	// streamClient is client without the WithTimeout
+	// limit, which would cut off streamed bodies
+	streamClient *http.Client
+	timeout      time.Duration
+	userAgent    string
+	headers      http.Header
+	retry        RetryPolicy
+	endpoints    *endpoints
+	endpointHook func(*http.Request, string)
+	auth         Authenticator
 }
 
-func newHttpClient() *httpClient {
+func newHttpClient(o options, eps *endpoints) (*httpClient, error) {
+	client := o.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	if o.timeout > 0 || o.tlsEnabled() {
+		// copy so the caller's client is left untouched
+		cc := *client
+		client = &cc
+	}
+	if o.timeout > 0 {
+		client.Timeout = o.timeout
+	}
+	if o.tlsEnabled() {
+		tr, err := o.tlsTransport(client.Transport)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = tr
+	}
+	streamClient := client
+	if o.timeout > 0 {
+		sc := *client
+		sc.Timeout = 0
+		streamClient = &sc
+	}
+	retry := DefaultRetryPolicy()
+	if o.retry != nil {
+		retry = *o.retry
+	}
 	return &httpClient{
-		client: http.Client{},
+		client:       client,
+		streamClient: streamClient,
+		timeout:      o.timeout,
+		userAgent:    o.userAgent,
+		headers:      o.headers,
+		retry:        retry,
+		endpoints:    eps,
+		endpointHook: o.endpointHook,
+		auth:         o.auth,
+	}, nil
+}
+
+// prepare adds the client wide headers to req.
+func (c *httpClient) prepare(req *http.Request) {
+	for k, vs := range c.headers {
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
 	}
 }
 
 func (c *httpClient) Do(req *http.Request) ([]byte, error) {
-	resp, err := c.client.Do(req)
+	var body []byte
+	err := c.send(req, false, func(resp *http.Response) error {
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		body = b
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return body, nil
+}
 
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+// Stream is like Do but returns the body of the response
+// unread. The caller must close it. The WithTimeout limit
+// only applies until the response arrives, reading the body
+// is bounded by the context of req alone.
+func (c *httpClient) Stream(req *http.Request) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := c.send(req, true, func(resp *http.Response) error {
+		body = resp.Body
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return body, nil
+}
+
+// send sends req, retrying and failing over as configured,
+// and passes the first 2xx response to handle. An error
+// returned by handle counts as a failed attempt. The body
+// of a stream response outlives send, see Stream.
+func (c *httpClient) send(req *http.Request, stream bool, handle func(*http.Response) error) error {
+	c.prepare(req)
+	ctx := req.Context()
+	replayable := c.retry.replayable(req)
+	probe := func(u *url.URL) bool {
+		return c.probe(ctx, u)
+	}
+
+	failovers := 0
+	refreshed := false
+	for attempt := 1; ; {
+		ep := c.endpoints.rebase(req.URL)
+		if err := c.authenticate(req); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return err
+		}
+		wait, err := c.do(req, ep, stream, handle)
+		if err == nil {
+			return nil
+		}
+
+		if r, ok := c.auth.(Refresher); ok && !refreshed && StatusCode(err) == http.StatusUnauthorized && rewindable(req) {
+			// a 401 means the request was not processed, so
+			// it is sent again whatever its method
+			refreshed = true
+			if rerr := r.Refresh(req); rerr != nil {
+				return rerr
+			}
+			if err := rewind(req); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if failovers < c.endpoints.len()-1 && failoverable(ctx, err) && c.endpoints.failover(ep, probe) {
+			// the next endpoint is known to be healthy, so
+			// there is no point in backing off
+			failovers++
+			wait = 0
+		} else {
+			if attempt >= c.retry.MaxAttempts || !retryable(ctx, err) {
+				return err
+			}
+			attempt++
+			if b := c.retry.backoff(attempt - 1); b > wait {
+				wait = b
+			}
+			if c.retry.MaxBackoff > 0 && wait > c.retry.MaxBackoff {
+				wait = c.retry.MaxBackoff
+			}
+		}
+		if !replayable {
+			return err
+		}
+
+		if serr := sleep(ctx, wait); serr != nil {
+			return err
+		}
+		if err := rewind(req); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *httpClient) authenticate(req *http.Request) error {
+	if c.auth == nil {
+		return nil
+	}
+	return c.auth.Authenticate(req)
+}
+
+// rewindable reports whether the body of req can be sent
+// again.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind resets the body of req before it is sent again.
+func rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// do sends req once to endpoint ep and passes a 2xx
+// response to handle. On failure it also returns the wait
+// requested by a Retry-After header, if any.
+func (c *httpClient) do(req *http.Request, ep int, stream bool, handle func(*http.Response) error) (time.Duration, error) {
+	roundTrip := c.client.Do
+	if stream {
+		roundTrip = c.stream
+	}
+	resp, err := roundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	if c.endpointHook != nil {
+		c.endpointHook(req, c.endpoints.at(ep).String())
+	}
+
 	if int(resp.StatusCode/100) != 2 {
-		return nil, fmt.Errorf("http status not 2xx: %d %s", resp.StatusCode, string(body))
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		return retryAfter(resp.Header), newAPIError(req, resp.StatusCode, body)
 	}
-	return body, nil
+	return 0, handle(resp)
+}
+
+// stream sends req through streamClient, giving up if no
+// response arrives within the WithTimeout limit.
+func (c *httpClient) stream(req *http.Request) (*http.Response, error) {
+	if c.timeout <= 0 {
+		return c.streamClient.Do(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(c.timeout, cancel)
+	resp, err := c.streamClient.Do(req.WithContext(ctx))
+	expired := !timer.Stop()
+	if err != nil {
+		cancel()
+		if expired {
+			err = fmt.Errorf("%s %s: no response within %s", req.Method, req.URL.Redacted(), c.timeout)
+		}
+		return nil, err
+	}
+	resp.Body = cancelBody{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelBody releases the context of a streamed response
+// once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
 }
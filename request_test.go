@@ -0,0 +1,355 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers the n-th request with responses[n],
+// repeating the last one, and records the requests.
+type scriptedServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	uris    []string
+	headers []http.Header
+	bodies  []string
+}
+
+type scriptedResponse struct {
+	status     int
+	retryAfter string
+	body       string
+}
+
+func newScriptedServer(t *testing.T, responses ...scriptedResponse) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		s.mu.Lock()
+		n := len(s.bodies)
+		s.uris = append(s.uris, r.Method+" "+r.URL.RequestURI())
+		s.headers = append(s.headers, r.Header)
+		s.bodies = append(s.bodies, string(b))
+		s.mu.Unlock()
+
+		resp := responses[len(responses)-1]
+		if n < len(responses) {
+			resp = responses[n]
+		}
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) hits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+var (
+	unavailable = scriptedResponse{status: http.StatusServiceUnavailable, body: `{"errors":["busy"]}`}
+	jobsOK      = scriptedResponse{status: http.StatusOK, body: `{"jobs":[]}`}
+	runOK       = scriptedResponse{status: http.StatusOK, body: `{"jobid":"b3c2ba2a8d9c5a4e7d1f0e6b9a8c7d6e"}`}
+	uploadOK    = scriptedResponse{status: http.StatusOK, body: `{"filename":"/tmp/upload/1234_test.jar","status":"success"}`}
+)
+
+func testRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    attempts,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     30 * time.Millisecond,
+		Multiplier:     2,
+	}
+	for i, want := range []time.Duration{10, 20, 30, 30} {
+		retry := i + 1
+		if got := p.backoff(retry); got != want*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", retry, got, want*time.Millisecond)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(1); got < 5*time.Millisecond || got > 10*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %v, want within [5ms, 10ms]", got)
+		}
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []scriptedResponse
+		attempts  int
+		wantHits  int
+		wantErr   bool
+	}{
+		{"success", []scriptedResponse{jobsOK}, 3, 1, false},
+		{"recovers", []scriptedResponse{unavailable, unavailable, jobsOK}, 3, 3, false},
+		{"exhausted", []scriptedResponse{unavailable}, 3, 3, true},
+		{"no retry", []scriptedResponse{unavailable}, 1, 1, true},
+		{"not retryable", []scriptedResponse{{status: http.StatusInternalServerError}}, 3, 1, true},
+		{"too many requests", []scriptedResponse{{status: http.StatusTooManyRequests}, jobsOK}, 3, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, tt.responses...)
+			c, err := New(srv.URL, WithRetryPolicy(testRetryPolicy(tt.attempts)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Jobs()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Jobs() error = %v, want error %v", err, tt.wantErr)
+			}
+			if srv.hits() != tt.wantHits {
+				t.Errorf("server got %d requests, want %d", srv.hits(), tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable, retryAfter: "1"}, jobsOK)
+	p := testRetryPolicy(2)
+	p.MaxBackoff = 5 * time.Second
+	c, err := New(srv.URL, WithRetryPolicy(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("retried after %v, want Retry-After of 1s honored", d)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable, retryAfter: "3600"}, jobsOK)
+	c, err := New(srv.URL, WithRetryPolicy(testRetryPolicy(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := c.Jobs(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("retried after %v, want Retry-After capped at MaxBackoff", d)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	for _, optIn := range []bool{false, true} {
+		srv := newScriptedServer(t, unavailable, runOK)
+		p := testRetryPolicy(3)
+		p.RetryNonIdempotent = optIn
+		c, err := New(srv.URL, WithRetryPolicy(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.RunJar(RunOpts{JarID: testJarID, ProgramArg: []string{"--input", "/data"}})
+
+		wantHits := 1
+		if optIn {
+			wantHits = 2
+		}
+		if (err == nil) != optIn {
+			t.Errorf("RetryNonIdempotent=%v: RunJar() error = %v", optIn, err)
+		}
+		if srv.hits() != wantHits {
+			t.Fatalf("RetryNonIdempotent=%v: server got %d requests, want %d", optIn, srv.hits(), wantHits)
+		}
+		if optIn && srv.bodies[0] != srv.bodies[1] {
+			t.Errorf("retried body %q, want %q", srv.bodies[1], srv.bodies[0])
+		}
+	}
+}
+
+// readerOnly hides every method of r but Read.
+type readerOnly struct {
+	r io.Reader
+}
+
+func (r readerOnly) Read(b []byte) (int, error) {
+	return r.r.Read(b)
+}
+
+func TestRetryReplayableBody(t *testing.T) {
+	jar := bytes.Repeat([]byte("jar"), 1000)
+	tests := []struct {
+		name     string
+		r        io.Reader
+		wantHits int
+	}{
+		{"seeker", bytes.NewReader(jar), 2},
+		{"reader", readerOnly{bytes.NewReader(jar)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, unavailable, uploadOK)
+			p := testRetryPolicy(3)
+			p.RetryNonIdempotent = true
+			c, err := New(srv.URL, WithRetryPolicy(p))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.UploadJarReader(context.Background(), "test.jar", tt.r, int64(len(jar)), nil)
+			if (err == nil) != (tt.wantHits == 2) {
+				t.Errorf("UploadJarReader() error = %v", err)
+			}
+			if srv.hits() != tt.wantHits {
+				t.Fatalf("server got %d requests, want %d", srv.hits(), tt.wantHits)
+			}
+			for _, b := range srv.bodies {
+				if !bytes.Contains([]byte(b), jar) {
+					t.Errorf("request body does not contain the whole jar")
+				}
+			}
+		})
+	}
+}
+
+func TestRetryCancelledDuringSleep(t *testing.T) {
+	srv := newScriptedServer(t, scriptedResponse{status: http.StatusServiceUnavailable, retryAfter: "30"})
+	p := testRetryPolicy(3)
+	p.MaxBackoff = time.Minute
+	c, err := New(srv.URL, WithRetryPolicy(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = c.JobsContext(ctx)
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("JobsContext() returned after %v, want it to stop sleeping on cancellation", d)
+	}
+	if StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("JobsContext() error = %v, want the last 503", err)
+	}
+	if srv.hits() != 1 {
+		t.Errorf("server got %d requests, want 1", srv.hits())
+	}
+}
+
+func TestRetryPlanJar(t *testing.T) {
+	srv := newScriptedServer(t, unavailable, scriptedResponse{status: http.StatusOK, body: `{"plan":{"jid":"b3c2ba2a8d9c5a4e7d1f0e6b9a8c7d6e","nodes":[]}}`})
+	c, err := New(srv.URL, WithRetryPolicy(testRetryPolicy(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.PlanJar(RunOpts{JarID: testJarID, Parallelism: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if srv.hits() != 2 || srv.bodies[0] != srv.bodies[1] {
+		t.Errorf("server got bodies %q, want the same body twice", srv.bodies)
+	}
+}
+
+func TestRetryTLSError(t *testing.T) {
+	var handshakes int32
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			atomic.AddInt32(&handshakes, 1)
+			return nil, nil
+		},
+	}
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	other := newScriptedServer(t, jobsOK)
+
+	// the certificate of srv is signed by a CA the client
+	// does not know
+	c, err := NewHA([]string{srv.URL, other.URL}, WithRetryPolicy(testRetryPolicy(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Jobs()
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Jobs() error = %v, want an unknown authority error", err)
+	}
+	if n := atomic.LoadInt32(&handshakes); n != 1 {
+		t.Errorf("server got %d handshakes, want 1", n)
+	}
+	if other.hits() != 0 || c.Endpoint() != srv.URL {
+		t.Errorf("failed over to %s after a certificate error", c.Endpoint())
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	logFile := bytes.Repeat([]byte("2024-06-10 INFO  org.apache.flink.runtime.taskexecutor.TaskExecutor\n"), 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/taskmanagers/" + testTMID + "/log":
+			// the body takes longer than the timeout
+			w.Write(logFile[:len(logFile)/2])
+			w.(http.Flusher).Flush()
+			time.Sleep(4 * timeout)
+			w.Write(logFile[len(logFile)/2:])
+		case "/jobmanager/stdout":
+			// so does the response
+			time.Sleep(4 * timeout)
+		}
+	}))
+	defer srv.Close()
+	c, err := New(srv.URL, WithTimeout(timeout), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := c.TaskManagerLog(testTMID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("reading the log: %v", err)
+	}
+	if !bytes.Equal(got, logFile) {
+		t.Errorf("read %d bytes of the log, want %d", len(got), len(logFile))
+	}
+
+	start := time.Now()
+	if _, err := c.JobManagerStdout(); err == nil {
+		t.Error("JobManagerStdout() succeeded, want the timeout to apply until the response arrives")
+	}
+	if d := time.Since(start); d > 3*timeout {
+		t.Errorf("JobManagerStdout() returned after %v, want about %v", d, timeout)
+	}
+}
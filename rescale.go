@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Rescale triggers the rescaling of a job to a new
+// parallelism. This legacy API is not supported by every
+// Flink version; prefer SetResourceRequirements with the
+// adaptive scheduler.
+func (c *Client) Rescale(jobID string, parallelism int) (*Operation, error) {
+	return c.RescaleContext(context.Background(), jobID, parallelism)
+}
+
+// RescaleContext is like Rescale but carries a context.
+func (c *Client) RescaleContext(ctx context.Context, jobID string, parallelism int) (*Operation, error) {
+	if parallelism < 1 {
+		return nil, fmt.Errorf("invalid parallelism %d", parallelism)
+	}
+	var r TriggerResp
+	uri := fmt.Sprintf("/jobs/%s/rescaling", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"PATCH",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	q := req.URL.Query()
+	q.Add("parallelism", strconv.Itoa(parallelism))
+	req.URL.RawQuery = q.Encode()
+	b, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return c.RescaleOperation(jobID, r.RequestID), nil
+}
+
+// RescaleOperation returns a handle on a rescaling
+// triggered by Rescale, given its request ID.
+func (c *Client) RescaleOperation(jobID, triggerID string) *Operation {
+	uri := fmt.Sprintf("/jobs/%s/rescaling/%s", jobID, triggerID)
+	return c.newOperation(triggerID, uri)
+}
+
+// JobResourceRequirements maps job vertex IDs to their
+// resource requirements.
+type JobResourceRequirements map[string]VertexResourceRequirements
+
+// VertexResourceRequirements are the resource requirements
+// of a job vertex.
+type VertexResourceRequirements struct {
+	Parallelism ParallelismBounds `json:"parallelism"`
+}
+
+// ParallelismBounds is the range of parallelism the
+// adaptive scheduler may run a job vertex with.
+type ParallelismBounds struct {
+	LowerBound int `json:"lowerBound"`
+	UpperBound int `json:"upperBound"`
+}
+
+// ResourceRequirements returns the resource requirements of
+// a job run by the adaptive scheduler.
+func (c *Client) ResourceRequirements(jobID string) (JobResourceRequirements, error) {
+	return c.ResourceRequirementsContext(context.Background(), jobID)
+}
+
+// ResourceRequirementsContext is like ResourceRequirements
+// but carries a context.
+func (c *Client) ResourceRequirementsContext(ctx context.Context, jobID string) (JobResourceRequirements, error) {
+	var r JobResourceRequirements
+	uri := fmt.Sprintf("/jobs/%s/resource-requirements", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// SetResourceRequirements changes the parallelism bounds of
+// the vertices of a job run by the adaptive scheduler,
+// which then rescales the job. The vertex IDs are checked
+// against Job before anything is sent.
+func (c *Client) SetResourceRequirements(jobID string, reqs JobResourceRequirements) error {
+	return c.SetResourceRequirementsContext(context.Background(), jobID, reqs)
+}
+
+// SetResourceRequirementsContext is like
+// SetResourceRequirements but carries a context.
+func (c *Client) SetResourceRequirementsContext(ctx context.Context, jobID string, reqs JobResourceRequirements) error {
+	if len(reqs) == 0 {
+		return fmt.Errorf("no resource requirements given")
+	}
+	job, err := c.JobContext(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if err := reqs.validate(job); err != nil {
+		return err
+	}
+
+	data := new(bytes.Buffer)
+	if err := json.NewEncoder(data).Encode(reqs); err != nil {
+		return err
+	}
+	uri := fmt.Sprintf("/jobs/%s/resource-requirements", jobID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"PUT",
+		c.url(uri),
+		data,
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = c.client.Do(req)
+	return err
+}
+
+// validate checks reqs against the vertices of job.
+func (reqs JobResourceRequirements) validate(job JobResp) error {
+	vertices := make(map[string]bool, len(job.Vertices))
+	for _, v := range job.Vertices {
+		vertices[v.ID] = true
+	}
+
+	ids := make([]string, 0, len(reqs))
+	for id := range reqs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if !vertices[id] {
+			return fmt.Errorf("job %s has no vertex %s", job.ID, id)
+		}
+		p := reqs[id].Parallelism
+		if p.UpperBound < 1 || p.LowerBound > p.UpperBound {
+			return fmt.Errorf("vertex %s: invalid parallelism bounds [%d, %d]", id, p.LowerBound, p.UpperBound)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const (
+	testJobID    = "b3c2ba2a8d9c5a4e7d1f0e6b9a8c7d6e"
+	testVertexID = "90bea66de1c231edf33913ecd54406c1"
+	testTMID     = "10.0.0.12:36719-a1b2c3"
+	testJarID    = "d2b2c6e0-1c5c-4a2c-9d9b-3f3a7e0b6c11_WordCount.jar"
+)
+
+// responseTests decode the captured flink responses in
+// testdata/<name>.json through the client method that
+// requests them.
+var responseTests = []struct {
+	name string
+	path string
+	call func(*Client) (interface{}, error)
+}{
+	{"config", "/config", func(c *Client) (interface{}, error) {
+		return c.Config()
+	}},
+	{"jars", "/jars", func(c *Client) (interface{}, error) {
+		return c.Jars()
+	}},
+	{"jar-upload", "/jars/upload", func(c *Client) (interface{}, error) {
+		fpath := filepath.Join("example", "testdata", "test.jar")
+		return c.UploadJar(fpath)
+	}},
+	{"jar-plan", "/jars/" + testJarID + "/plan", func(c *Client) (interface{}, error) {
+		return c.PlanJar(RunOpts{JarID: testJarID})
+	}},
+	{"jar-run", "/jars/" + testJarID + "/run", func(c *Client) (interface{}, error) {
+		return c.RunJar(RunOpts{JarID: testJarID})
+	}},
+	{"jobmanager-config", "/jobmanager/config", func(c *Client) (interface{}, error) {
+		return c.JobManagerConfig()
+	}},
+	{"jobmanager-metrics", "/jobmanager/metrics", func(c *Client) (interface{}, error) {
+		return c.JobManagerMetrics("Status.JVM.Memory.Heap.Used", "numRunningJobs")
+	}},
+	{"jobmanager-environment", "/jobmanager/environment", func(c *Client) (interface{}, error) {
+		return c.JobManagerEnvironment()
+	}},
+	{"jobs", "/jobs", func(c *Client) (interface{}, error) {
+		return c.Jobs()
+	}},
+	{"jobs-metrics", "/jobs/metrics", func(c *Client) (interface{}, error) {
+		return c.JobMetrics(JobMetricsOpts{
+			Metrics: []string{"numRestarts", "lastCheckpointSize"},
+			Agg:     []string{"min", "max", "avg", "sum"},
+		})
+	}},
+	{"jobs-overview", "/jobs/overview", func(c *Client) (interface{}, error) {
+		return c.JobsOverview()
+	}},
+	{"job", "/jobs/" + testJobID, func(c *Client) (interface{}, error) {
+		return c.Job(testJobID)
+	}},
+	{"job-config", "/jobs/" + testJobID + "/config", func(c *Client) (interface{}, error) {
+		return c.JobConfig(testJobID)
+	}},
+	{"job-exceptions", "/jobs/" + testJobID + "/exceptions", func(c *Client) (interface{}, error) {
+		return c.JobExceptions(testJobID, 10)
+	}},
+	{"job-execution-result", "/jobs/" + testJobID + "/execution-result", func(c *Client) (interface{}, error) {
+		return c.JobExecutionResult(testJobID)
+	}},
+	{"job-checkpoints", "/jobs/" + testJobID + "/checkpoints", func(c *Client) (interface{}, error) {
+		return c.Checkpoints(testJobID)
+	}},
+	{"checkpoint-config", "/jobs/" + testJobID + "/checkpoints/config", func(c *Client) (interface{}, error) {
+		return c.CheckpointConfig(testJobID)
+	}},
+	{"checkpoint-details", "/jobs/" + testJobID + "/checkpoints/details/5", func(c *Client) (interface{}, error) {
+		return c.CheckpointDetails(testJobID, 5)
+	}},
+	{"checkpoint-subtasks", "/jobs/" + testJobID + "/checkpoints/details/5/subtasks/" + testVertexID, func(c *Client) (interface{}, error) {
+		return c.CheckpointSubtasks(testJobID, 5, testVertexID)
+	}},
+	{"savepoint-status", "/jobs/" + testJobID + "/savepoints/7d1f0e6b", func(c *Client) (interface{}, error) {
+		return c.SavepointOperation(testJobID, "7d1f0e6b").Status()
+	}},
+	{"resource-requirements", "/jobs/" + testJobID + "/resource-requirements", func(c *Client) (interface{}, error) {
+		return c.ResourceRequirements(testJobID)
+	}},
+	{"overview", "/overview", func(c *Client) (interface{}, error) {
+		return c.Overview()
+	}},
+	{"datasets", "/datasets", func(c *Client) (interface{}, error) {
+		return c.Datasets()
+	}},
+	{"taskmanagers", "/taskmanagers", func(c *Client) (interface{}, error) {
+		return c.TaskManagers()
+	}},
+	{"taskmanager", "/taskmanagers/" + testTMID, func(c *Client) (interface{}, error) {
+		return c.TaskManager(testTMID)
+	}},
+	{"logs", "/taskmanagers/" + testTMID + "/logs", func(c *Client) (interface{}, error) {
+		return c.TaskManagerLogs(testTMID)
+	}},
+	{"thread-dump", "/jobmanager/thread-dump", func(c *Client) (interface{}, error) {
+		return c.JobManagerThreadDump()
+	}},
+	{"vertex", "/jobs/" + testJobID + "/vertices/" + testVertexID, func(c *Client) (interface{}, error) {
+		return c.Vertex(testJobID, testVertexID)
+	}},
+	{"vertex-subtasktimes", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/subtasktimes", func(c *Client) (interface{}, error) {
+		return c.SubtaskTimes(testJobID, testVertexID)
+	}},
+	{"vertex-taskmanagers", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/taskmanagers", func(c *Client) (interface{}, error) {
+		return c.VertexTaskManagers(testJobID, testVertexID)
+	}},
+	{"vertex-accumulators", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/subtasks/accumulators", func(c *Client) (interface{}, error) {
+		return c.SubtaskAccumulators(testJobID, testVertexID)
+	}},
+	{"vertex-backpressure", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/backpressure", func(c *Client) (interface{}, error) {
+		return c.BackPressure(testJobID, testVertexID)
+	}},
+	{"vertex-flamegraph", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/flamegraph", func(c *Client) (interface{}, error) {
+		return c.FlameGraph(testJobID, testVertexID, FlameGraphFull)
+	}},
+	{"watermarks", "/jobs/" + testJobID + "/vertices/" + testVertexID + "/watermarks", func(c *Client) (interface{}, error) {
+		return c.Watermarks(testJobID, testVertexID)
+	}},
+}
+
+// TestResponses checks every captured response against its
+// golden file, testdata/<name>.golden, and that no field
+// of the captured response is lost when the decoded value
+// is encoded again.
+func TestResponses(t *testing.T) {
+	for _, tt := range responseTests {
+		t.Run(tt.name, func(t *testing.T) {
+			captured, err := ioutil.ReadFile(filepath.Join("testdata", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(captured)
+			}))
+			defer srv.Close()
+			c, err := New(srv.URL, WithRetryPolicy(NoRetry()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tt.call(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded = append(encoded, '\n')
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := ioutil.WriteFile(golden, encoded, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, want) {
+				t.Errorf("decoded %s differs from %s:\n%s", tt.name, golden, encoded)
+			}
+
+			var in, out interface{}
+			if err := json.Unmarshal(captured, &in); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(encoded, &out); err != nil {
+				t.Fatal(err)
+			}
+			for _, l := range lostFields(reflect.TypeOf(got), in, out, "") {
+				t.Errorf("field %s does not round-trip", l)
+			}
+		})
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// lostFields returns the paths of the fields of typ present
+// in the captured JSON in but missing from, or different
+// in, the re-encoded JSON out. Fields typ does not model
+// are ignored.
+func lostFields(typ reflect.Type, in, out interface{}, path string) []string {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if in == nil {
+		return nil
+	}
+	if reflect.PtrTo(typ).Implements(unmarshalerType) {
+		return compareLeaf(in, out, path)
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		inObj, _ := in.(map[string]interface{})
+		outObj, _ := out.(map[string]interface{})
+		return lostStructFields(typ, inObj, outObj, path)
+	case reflect.Slice:
+		inArr, _ := in.([]interface{})
+		outArr, _ := out.([]interface{})
+		if len(inArr) != len(outArr) {
+			return []string{path}
+		}
+		var lost []string
+		for i := range inArr {
+			lost = append(lost, lostFields(typ.Elem(), inArr[i], outArr[i], fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return lost
+	case reflect.Map:
+		inObj, _ := in.(map[string]interface{})
+		outObj, _ := out.(map[string]interface{})
+		var lost []string
+		for k, v := range inObj {
+			o, ok := outObj[k]
+			if !ok {
+				lost = append(lost, path+"."+k)
+				continue
+			}
+			lost = append(lost, lostFields(typ.Elem(), v, o, path+"."+k)...)
+		}
+		return lost
+	}
+	return compareLeaf(in, out, path)
+}
+
+func lostStructFields(typ reflect.Type, in, out map[string]interface{}, path string) []string {
+	var lost []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.Anonymous && name == "" {
+			lost = append(lost, lostStructFields(f.Type, in, out, path)...)
+			continue
+		}
+		if f.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		v, ok := lookupKey(in, name)
+		if !ok {
+			continue
+		}
+		o, ok := lookupKey(out, name)
+		if !ok {
+			if v != nil {
+				lost = append(lost, path+"."+name)
+			}
+			continue
+		}
+		lost = append(lost, lostFields(f.Type, v, o, path+"."+name)...)
+	}
+	return lost
+}
+
+// lookupKey finds name in obj the way encoding/json
+// does, preferring an exact match.
+func lookupKey(obj map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := obj[name]; ok {
+		return v, true
+	}
+	for k, v := range obj {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func compareLeaf(in, out interface{}, path string) []string {
+	if !reflect.DeepEqual(in, out) {
+		return []string{fmt.Sprintf("%s (%v != %v)", path, in, out)}
+	}
+	return nil
+}
+
+func decodeFixture(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTypedResponseFields(t *testing.T) {
+	var run RunResp
+	decodeFixture(t, "jar-run", &run)
+	if run.ID != testJobID {
+		t.Errorf("RunResp.ID = %q, want %q", run.ID, testJobID)
+	}
+
+	var metrics []AggregatedMetric
+	decodeFixture(t, "jobs-metrics", &metrics)
+	if m := metrics[1]; m.Min == nil || *m.Min != 0 || m.Avg != nil || m.Sum != nil {
+		t.Errorf("lastCheckpointSize = %+v, want only min and max set", m)
+	}
+
+	var overview JobsOverviewResp
+	decodeFixture(t, "jobs-overview", &overview)
+	job := overview.Jobs[0]
+	if job.State != JobStatusRunning || job.Tasks.Total != 3 || job.Tasks.Running != 3 {
+		t.Errorf("job overview = %+v", job)
+	}
+	if !job.End.IsZero() || job.Start.Time().UnixNano()/1e6 != 1718000600000 {
+		t.Errorf("job times = %v - %v", job.Start, job.End)
+	}
+
+	var detail JobResp
+	decodeFixture(t, "job", &detail)
+	v := detail.Vertices[1]
+	if v.Status != ExecutionStateRunning || v.Tasks.Running != 2 || v.Metrics.ReadRecords != 1024 || v.Metrics.AccumulatedIdleTime.Duration() != 9876*time.Millisecond {
+		t.Errorf("vertex = %+v", v)
+	}
+	if detail.Timestamps.Get(JobStatusRunning).IsZero() || !detail.Timestamps.Get(JobStatusFailed).IsZero() {
+		t.Errorf("timestamps = %+v", detail.Timestamps)
+	}
+
+	var cps CheckpointsResp
+	decodeFixture(t, "job-checkpoints", &cps)
+	if cps.Latest.Completed == nil || cps.Latest.Completed.Class != "completed" || cps.Latest.Completed.ID != 5 {
+		t.Errorf("latest completed = %+v", cps.Latest.Completed)
+	}
+	if f := cps.Latest.Failed; f == nil || f.Class != "failed" || f.FailureMessage == "" || !f.LatestAckTimestamp.IsZero() {
+		t.Errorf("latest failed = %+v", f)
+	}
+	if r := cps.Latest.Restored; r == nil || !r.IsSavepoint || r.ID != 2 {
+		t.Errorf("latest restored = %+v", r)
+	}
+	if p := cps.Summary.End2EndDuration.P99; p != 48 {
+		t.Errorf("end to end p99 = %v, want 48", p)
+	}
+}
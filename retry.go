@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries requests that
+// failed with a connection error or a transient status code
+// (429, 502, 503 or 504). TLS errors, such as a server
+// certificate that does not verify, are not retried.
+//
+// Only idempotent requests (GET and HEAD, and read-only
+// calls such as PlanJar) are retried unless
+// RetryNonIdempotent is set. Requests whose body cannot be
+// replayed are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, the
+	// first one included. Values below 2 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry.
+	// It grows by Multiplier after each attempt and is
+	// capped at MaxBackoff. A longer wait requested by a
+	// Retry-After header is honored up to MaxBackoff too.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Jitter is the fraction, between 0 and 1, by which
+	// each backoff is randomly shortened.
+	Jitter float64
+
+	// RetryNonIdempotent allows retrying calls such as
+	// RunJar, SavePoints or Shutdown, which may not be safe
+	// to repeat.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns the policy used when New is
+// not given WithRetryPolicy.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// NoRetry returns a policy that disables retries.
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// WithRetryPolicy replaces the default retry policy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *options) error {
+		if p.Jitter < 0 || p.Jitter > 1 {
+			return errors.New("retry jitter must be between 0 and 1")
+		}
+		o.retry = &p
+		return nil
+	}
+}
+
+type idempotentKey struct{}
+
+// idempotent marks the requests made with ctx as safe to
+// repeat whatever their method.
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// replayable reports whether req may be sent more than
+// once.
+func (p RetryPolicy) replayable(req *http.Request) bool {
+	if !rewindable(req) {
+		return false
+	}
+	if req.Method == "GET" || req.Method == "HEAD" {
+		return true
+	}
+	if ok, _ := req.Context().Value(idempotentKey{}).(bool); ok {
+		return true
+	}
+	return p.RetryNonIdempotent
+}
+
+// backoff returns the wait before the given retry, which
+// starts at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(mult, float64(retry-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	d -= d * p.Jitter * rand.Float64()
+	return time.Duration(d)
+}
+
+// retryable reports whether a failed attempt is worth
+// another try on the same endpoint.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var e *APIError
+	if errors.As(err, &e) {
+		switch e.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// anything else is a transport error such as a
+	// refused or reset connection
+	return !permanent(err)
+}
+
+// failoverable reports whether a failed attempt is worth
+// another try on a different endpoint.
+func failoverable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode/100 == 5
+	}
+	return !permanent(err)
+}
+
+// permanent reports whether a transport error will happen
+// again however often the request is sent, such as a
+// certificate that does not verify.
+func permanent(err error) bool {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	return errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostname) ||
+		errors.As(err, &invalid) ||
+		errors.As(err, &recordHeader)
+}
+
+// retryAfter parses a Retry-After header given either in
+// seconds or as an HTTP date.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
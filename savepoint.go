@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DisposeSavepoint triggers the disposal of the savepoint
+// at path, deleting its data and metadata.
+func (c *Client) DisposeSavepoint(path string) (*Operation, error) {
+	return c.DisposeSavepointContext(context.Background(), path)
+}
+
+// DisposeSavepointContext is like DisposeSavepoint but
+// carries a context.
+func (c *Client) DisposeSavepointContext(ctx context.Context, path string) (*Operation, error) {
+	type disposalReq struct {
+		Path string `json:"savepoint-path"`
+	}
+	var r TriggerResp
+
+	if path == "" {
+		return nil, fmt.Errorf("savepoint path is empty")
+	}
+	d := disposalReq{
+		Path: path,
+	}
+	data := new(bytes.Buffer)
+	if err := json.NewEncoder(data).Encode(d); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		c.url("/savepoint-disposal"),
+		data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	b, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return c.DisposeSavepointOperation(r.RequestID), nil
+}
+
+// DisposeSavepointOperation returns a handle on a disposal
+// triggered by DisposeSavepoint, given its request ID.
+func (c *Client) DisposeSavepointOperation(triggerID string) *Operation {
+	uri := fmt.Sprintf("/savepoint-disposal/%s", triggerID)
+	return c.newOperation(triggerID, uri)
+}
+
+// SavepointRecord is a savepoint known by its path and the
+// time it was taken.
+type SavepointRecord struct {
+	Path string
+	Time time.Time
+}
+
+// PruneSavepointsOpts are the arguments of PruneSavepoints.
+type PruneSavepointsOpts struct {
+	// KeepLast: number of most recent savepoints that are
+	// never disposed.
+	KeepLast int
+
+	// OlderThan: only savepoints taken longer ago than
+	// this are disposed.
+	OlderThan time.Duration
+
+	// Records (optional): savepoints of the job tracked by
+	// the caller, in addition to the ones flink still
+	// reports in its checkpoint history. Records without
+	// a time count as the oldest ones.
+	Records []SavepointRecord
+
+	// DryRun (optional): only return the savepoints that
+	// would be disposed.
+	DryRun bool
+
+	// PollInterval (optional): interval at which each
+	// disposal is polled until it completes.
+	PollInterval time.Duration
+}
+
+// PruneSavepoints disposes the savepoints of a job older
+// than opts.OlderThan, except the opts.KeepLast most recent
+// ones and the one the job was restored from. Savepoints
+// are taken from the checkpoint statistics of the job and
+// from opts.Records. It returns the disposed savepoints,
+// or those that would be disposed in a dry run, newest
+// first. On error the savepoints disposed so far are
+// returned.
+func (c *Client) PruneSavepoints(ctx context.Context, jobID string, opts PruneSavepointsOpts) ([]SavepointRecord, error) {
+	if opts.KeepLast < 0 {
+		return nil, fmt.Errorf("invalid KeepLast %d", opts.KeepLast)
+	}
+	cps, err := c.CheckpointsContext(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := pruneCandidates(cps, opts, time.Now())
+	if opts.DryRun {
+		return candidates, nil
+	}
+
+	var disposed []SavepointRecord
+	for _, s := range candidates {
+		op, err := c.DisposeSavepointContext(ctx, s.Path)
+		if err != nil {
+			return disposed, err
+		}
+		if _, err := op.Wait(ctx, opts.PollInterval); err != nil {
+			return disposed, err
+		}
+		disposed = append(disposed, s)
+	}
+	return disposed, nil
+}
+
+// pruneCandidates returns the savepoints PruneSavepoints
+// disposes, newest first. Savepoints taken at the same
+// time are ordered by path, so that the same ones are kept
+// on every run.
+func pruneCandidates(cps CheckpointsResp, opts PruneSavepointsOpts, now time.Time) []SavepointRecord {
+	byPath := make(map[string]SavepointRecord)
+	add := func(s SavepointRecord) {
+		if s.Path == "" {
+			return
+		}
+		if old, ok := byPath[s.Path]; !ok || s.Time.After(old.Time) {
+			byPath[s.Path] = s
+		}
+	}
+	fromStats := func(cp *CheckpointStatistics) {
+		if cp == nil || !cp.IsSavepoint || cp.Discarded || cp.Status != "COMPLETED" {
+			return
+		}
+		add(SavepointRecord{Path: cp.ExternalPath, Time: cp.TriggerTimestamp.Time()})
+	}
+
+	fromStats(cps.Latest.Savepoint)
+	for i := range cps.History {
+		fromStats(&cps.History[i])
+	}
+	for _, s := range opts.Records {
+		add(s)
+	}
+
+	var restored string
+	if r := cps.Latest.Restored; r != nil {
+		restored = r.ExternalPath
+	}
+
+	all := make([]SavepointRecord, 0, len(byPath))
+	for _, s := range byPath {
+		all = append(all, s)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].Time.Equal(all[j].Time) {
+			return all[i].Time.After(all[j].Time)
+		}
+		return all[i].Path < all[j].Path
+	})
+
+	var candidates []SavepointRecord
+	cutoff := now.Add(-opts.OlderThan)
+	for i, s := range all {
+		if i < opts.KeepLast || s.Path == restored || !s.Time.Before(cutoff) {
+			continue
+		}
+		candidates = append(candidates, s)
+	}
+	return candidates
+}
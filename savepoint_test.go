@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Unix(1718000000, 0)
+	at := func(age time.Duration) time.Time {
+		return now.Add(-age)
+	}
+	savepoint := func(path string, age time.Duration) CheckpointStatistics {
+		return CheckpointStatistics{
+			Status:           "COMPLETED",
+			IsSavepoint:      true,
+			ExternalPath:     path,
+			TriggerTimestamp: Timestamp(at(age).UnixNano() / int64(time.Millisecond)),
+		}
+	}
+	history := []CheckpointStatistics{
+		savepoint("sp-1h", time.Hour),
+		savepoint("sp-2h", 2*time.Hour),
+		savepoint("sp-3h", 3*time.Hour),
+		savepoint("sp-4h", 4*time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		cps      CheckpointsResp
+		opts     PruneSavepointsOpts
+		restored string
+		want     []string
+	}{
+		{
+			name: "keep last",
+			cps:  CheckpointsResp{History: history},
+			opts: PruneSavepointsOpts{KeepLast: 2},
+			want: []string{"sp-3h", "sp-4h"},
+		},
+		{
+			name: "keep more than there are",
+			cps:  CheckpointsResp{History: history},
+			opts: PruneSavepointsOpts{KeepLast: 5},
+		},
+		{
+			name:     "restored is kept",
+			cps:      CheckpointsResp{History: history},
+			restored: "sp-4h",
+			want:     []string{"sp-1h", "sp-2h", "sp-3h"},
+		},
+		{
+			name: "older than",
+			cps:  CheckpointsResp{History: history},
+			opts: PruneSavepointsOpts{OlderThan: 150 * time.Minute},
+			want: []string{"sp-3h", "sp-4h"},
+		},
+		{
+			name: "older than zero cuts off now",
+			cps: CheckpointsResp{History: []CheckpointStatistics{
+				savepoint("sp-now", 0),
+				savepoint("sp-1h", time.Hour),
+			}},
+			want: []string{"sp-1h"},
+		},
+		{
+			name: "latest savepoint",
+			cps: CheckpointsResp{
+				Latest:  LatestCheckpoints{Savepoint: &history[0]},
+				History: history[1:2],
+			},
+			want: []string{"sp-1h", "sp-2h"},
+		},
+		{
+			name: "zero time records are oldest, by path",
+			cps:  CheckpointsResp{History: history[:1]},
+			opts: PruneSavepointsOpts{
+				KeepLast: 2,
+				Records: []SavepointRecord{
+					{Path: "rec-c"},
+					{Path: "rec-a"},
+					{Path: "rec-b"},
+				},
+			},
+			want: []string{"rec-b", "rec-c"},
+		},
+		{
+			name: "records merged with stats",
+			cps:  CheckpointsResp{History: history[2:3]},
+			opts: PruneSavepointsOpts{
+				KeepLast: 1,
+				Records: []SavepointRecord{
+					{Path: "sp-3h", Time: at(30 * time.Minute)},
+					{Path: "rec-5h", Time: at(5 * time.Hour)},
+					{Path: ""},
+				},
+			},
+			want: []string{"rec-5h"},
+		},
+		{
+			name: "skipped stats",
+			cps: CheckpointsResp{History: []CheckpointStatistics{
+				func() CheckpointStatistics {
+					s := savepoint("discarded", time.Hour)
+					s.Discarded = true
+					return s
+				}(),
+				func() CheckpointStatistics {
+					s := savepoint("failed", time.Hour)
+					s.Status = "FAILED"
+					return s
+				}(),
+				func() CheckpointStatistics {
+					s := savepoint("checkpoint", time.Hour)
+					s.IsSavepoint = false
+					return s
+				}(),
+				savepoint("", time.Hour),
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.restored != "" {
+				tt.cps.Latest.Restored = &RestoredCheckpointStatistics{IsSavepoint: true, ExternalPath: tt.restored}
+			}
+			// the savepoints are collected in a map, run
+			// several times to catch an unstable order
+			for i := 0; i < 20; i++ {
+				var got []string
+				for _, s := range pruneCandidates(tt.cps, tt.opts, now) {
+					got = append(got, s.Path)
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Fatalf("pruneCandidates() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JobStatus is the status of a job. Statuses introduced by
+// Flink versions newer than this package are kept as is.
+type JobStatus string
+
+// Job statuses known to Flink.
+const (
+	JobStatusInitializing JobStatus = "INITIALIZING"
+	JobStatusCreated      JobStatus = "CREATED"
+	JobStatusRunning      JobStatus = "RUNNING"
+	JobStatusFailing      JobStatus = "FAILING"
+	JobStatusFailed       JobStatus = "FAILED"
+	JobStatusCancelling   JobStatus = "CANCELLING"
+	JobStatusCanceled     JobStatus = "CANCELED"
+	JobStatusFinished     JobStatus = "FINISHED"
+	JobStatusRestarting   JobStatus = "RESTARTING"
+	JobStatusSuspended    JobStatus = "SUSPENDED"
+	JobStatusReconciling  JobStatus = "RECONCILING"
+)
+
+// Known reports whether s is one of the JobStatus
+// constants.
+func (s JobStatus) Known() bool {
+	switch s {
+	case JobStatusInitializing, JobStatusCreated, JobStatusRunning,
+		JobStatusFailing, JobStatusFailed, JobStatusCancelling,
+		JobStatusCanceled, JobStatusFinished, JobStatusRestarting,
+		JobStatusSuspended, JobStatusReconciling:
+		return true
+	}
+	return false
+}
+
+// IsGloballyTerminal reports whether the job is done for
+// the whole cluster: it finished, failed or was canceled
+// and will not be recovered.
+func (s JobStatus) IsGloballyTerminal() bool {
+	switch s {
+	case JobStatusFinished, JobStatusFailed, JobStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether the job stopped running on
+// this job manager. Besides the globally terminal
+// statuses this includes SUSPENDED, from which another job
+// manager may recover the job.
+func (s JobStatus) IsTerminal() bool {
+	return s.IsGloballyTerminal() || s == JobStatusSuspended
+}
+
+func (s JobStatus) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON accepts any status string, including ones
+// unknown to this package, and null.
+func (s *JobStatus) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalState(b)
+	*s = JobStatus(v)
+	return err
+}
+
+// ExecutionState is the state of a task, i.e. a subtask of
+// a job vertex, or the aggregated state of a vertex.
+// States introduced by Flink versions newer than this
+// package are kept as is.
+type ExecutionState string
+
+// Execution states known to Flink.
+const (
+	ExecutionStateCreated      ExecutionState = "CREATED"
+	ExecutionStateScheduled    ExecutionState = "SCHEDULED"
+	ExecutionStateDeploying    ExecutionState = "DEPLOYING"
+	ExecutionStateInitializing ExecutionState = "INITIALIZING"
+	ExecutionStateRunning      ExecutionState = "RUNNING"
+	ExecutionStateFinished     ExecutionState = "FINISHED"
+	ExecutionStateCanceling    ExecutionState = "CANCELING"
+	ExecutionStateCanceled     ExecutionState = "CANCELED"
+	ExecutionStateFailed       ExecutionState = "FAILED"
+	ExecutionStateReconciling  ExecutionState = "RECONCILING"
+)
+
+// Known reports whether s is one of the ExecutionState
+// constants.
+func (s ExecutionState) Known() bool {
+	switch s {
+	case ExecutionStateCreated, ExecutionStateScheduled, ExecutionStateDeploying,
+		ExecutionStateInitializing, ExecutionStateRunning, ExecutionStateFinished,
+		ExecutionStateCanceling, ExecutionStateCanceled, ExecutionStateFailed,
+		ExecutionStateReconciling:
+		return true
+	}
+	return false
+}
+
+// IsTerminal reports whether the task is done: it
+// finished, failed or was canceled.
+func (s ExecutionState) IsTerminal() bool {
+	switch s {
+	case ExecutionStateFinished, ExecutionStateCanceled, ExecutionStateFailed:
+		return true
+	}
+	return false
+}
+
+func (s ExecutionState) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON accepts any state string, including ones
+// unknown to this package, and null.
+func (s *ExecutionState) UnmarshalJSON(b []byte) error {
+	v, err := unmarshalState(b)
+	*s = ExecutionState(v)
+	return err
+}
+
+// unmarshalState decodes a JSON string or null into an
+// upper case state name.
+func unmarshalState(b []byte) (string, error) {
+	var v *string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", nil
+	}
+	return strings.ToUpper(*v), nil
+}
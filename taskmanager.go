@@ -0,0 +1,334 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TaskManagersResp is the answer of TaskManagers.
+type TaskManagersResp struct {
+	TaskManagers []TaskManagerInfo `json:"taskmanagers"`
+}
+
+// TaskManagerInfo describes a task manager and its slots.
+type TaskManagerInfo struct {
+	ID                     string              `json:"id"`
+	Path                   string              `json:"path"`
+	DataPort               int                 `json:"dataPort"`
+	JmxPort                int                 `json:"jmxPort"`
+	TimeSinceLastHeartbeat Timestamp           `json:"timeSinceLastHeartbeat"`
+	SlotsNumber            int                 `json:"slotsNumber"`
+	FreeSlots              int                 `json:"freeSlots"`
+	TotalResource          Resource            `json:"totalResource"`
+	FreeResource           Resource            `json:"freeResource"`
+	Hardware               Hardware            `json:"hardware"`
+	MemoryConfiguration    MemoryConfiguration `json:"memoryConfiguration"`
+	Blocked                bool                `json:"blocked"`
+	Metrics                *TaskManagerMetrics `json:"metrics,omitempty"`
+	AllocatedSlots         []AllocatedSlotInfo `json:"allocatedSlots,omitempty"`
+}
+
+// Resource is an amount of CPU and memory of a task
+// manager or slot. Memory sizes are in megabytes.
+type Resource struct {
+	CPUCores          float64            `json:"cpuCores"`
+	TaskHeapMemory    int64              `json:"taskHeapMemory"`
+	TaskOffHeapMemory int64              `json:"taskOffHeapMemory"`
+	ManagedMemory     int64              `json:"managedMemory"`
+	NetworkMemory     int64              `json:"networkMemory"`
+	ExtendedResources map[string]float64 `json:"extendedResources"`
+}
+
+// Hardware describes the machine of a task manager. Memory
+// sizes are in bytes.
+type Hardware struct {
+	CPUCores       int   `json:"cpuCores"`
+	PhysicalMemory int64 `json:"physicalMemory"`
+	FreeMemory     int64 `json:"freeMemory"`
+	ManagedMemory  int64 `json:"managedMemory"`
+}
+
+// MemoryConfiguration is the memory layout of a task
+// manager process, in bytes.
+type MemoryConfiguration struct {
+	FrameworkHeap      int64 `json:"frameworkHeap"`
+	TaskHeap           int64 `json:"taskHeap"`
+	FrameworkOffHeap   int64 `json:"frameworkOffHeap"`
+	TaskOffHeap        int64 `json:"taskOffHeap"`
+	NetworkMemory      int64 `json:"networkMemory"`
+	ManagedMemory      int64 `json:"managedMemory"`
+	JvmMetaspace       int64 `json:"jvmMetaspace"`
+	JvmOverhead        int64 `json:"jvmOverhead"`
+	TotalFlinkMemory   int64 `json:"totalFlinkMemory"`
+	TotalProcessMemory int64 `json:"totalProcessMemory"`
+}
+
+// TaskManagerMetrics is the memory and garbage collection
+// state of a task manager, in bytes.
+type TaskManagerMetrics struct {
+	HeapUsed         int64 `json:"heapUsed"`
+	HeapCommitted    int64 `json:"heapCommitted"`
+	HeapMax          int64 `json:"heapMax"`
+	NonHeapUsed      int64 `json:"nonHeapUsed"`
+	NonHeapCommitted int64 `json:"nonHeapCommitted"`
+	NonHeapMax       int64 `json:"nonHeapMax"`
+	DirectCount      int64 `json:"directCount"`
+	DirectUsed       int64 `json:"directUsed"`
+	DirectMax        int64 `json:"directMax"`
+	MappedCount      int64 `json:"mappedCount"`
+	MappedUsed       int64 `json:"mappedUsed"`
+	MappedMax        int64 `json:"mappedMax"`
+
+	MemorySegmentsAvailable int64 `json:"memorySegmentsAvailable"`
+	MemorySegmentsTotal     int64 `json:"memorySegmentsTotal"`
+
+	NettyShuffleMemorySegmentsAvailable int64 `json:"nettyShuffleMemorySegmentsAvailable"`
+	NettyShuffleMemorySegmentsUsed      int64 `json:"nettyShuffleMemorySegmentsUsed"`
+	NettyShuffleMemorySegmentsTotal     int64 `json:"nettyShuffleMemorySegmentsTotal"`
+	NettyShuffleMemoryAvailable         int64 `json:"nettyShuffleMemoryAvailable"`
+	NettyShuffleMemoryUsed              int64 `json:"nettyShuffleMemoryUsed"`
+	NettyShuffleMemoryTotal             int64 `json:"nettyShuffleMemoryTotal"`
+
+	GarbageCollectors []GarbageCollector `json:"garbageCollectors"`
+}
+
+// GarbageCollector is the activity of a JVM garbage
+// collector. Time is in milliseconds.
+type GarbageCollector struct {
+	Name  string   `json:"name"`
+	Count int64    `json:"count"`
+	Time  Duration `json:"time"`
+}
+
+// AllocatedSlotInfo is a slot of a task manager allocated
+// to a job.
+type AllocatedSlotInfo struct {
+	JobID    string   `json:"jobId"`
+	Resource Resource `json:"resource"`
+}
+
+// LogsResp lists the log files of a job manager or task
+// manager.
+type LogsResp struct {
+	Logs []LogInfo `json:"logs"`
+}
+
+// LogInfo is a log file. Size is in bytes.
+type LogInfo struct {
+	Name  string    `json:"name"`
+	Size  int64     `json:"size"`
+	MTime Timestamp `json:"mtime,omitempty"`
+}
+
+// ThreadDumpResp is a thread dump of a job manager or task
+// manager.
+type ThreadDumpResp struct {
+	ThreadInfos []ThreadInfo `json:"threadInfos"`
+}
+
+// ThreadInfo is the stack of a thread.
+type ThreadInfo struct {
+	ThreadName            string `json:"threadName"`
+	StringifiedThreadInfo string `json:"stringifiedThreadInfo"`
+}
+
+// TaskManagers returns an overview over all task managers.
+func (c *Client) TaskManagers() (TaskManagersResp, error) {
+	return c.TaskManagersContext(context.Background())
+}
+
+// TaskManagersContext is like TaskManagers but carries a
+// context.
+func (c *Client) TaskManagersContext(ctx context.Context) (TaskManagersResp, error) {
+	var r TaskManagersResp
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url("/taskmanagers"),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// TaskManager returns details of a task manager, including
+// its memory metrics and allocated slots.
+func (c *Client) TaskManager(tmID string) (TaskManagerInfo, error) {
+	return c.TaskManagerContext(context.Background(), tmID)
+}
+
+// TaskManagerContext is like TaskManager but carries a
+// context.
+func (c *Client) TaskManagerContext(ctx context.Context, tmID string) (TaskManagerInfo, error) {
+	var r TaskManagerInfo
+	uri := fmt.Sprintf("/taskmanagers/%s", url.PathEscape(tmID))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// TaskManagerMetrics lists the metrics of a task manager,
+// or returns the values of the given metrics.
+func (c *Client) TaskManagerMetrics(tmID string, metrics ...string) ([]Metric, error) {
+	return c.TaskManagerMetricsContext(context.Background(), tmID, metrics...)
+}
+
+// TaskManagerMetricsContext is like TaskManagerMetrics but
+// carries a context.
+func (c *Client) TaskManagerMetricsContext(ctx context.Context, tmID string, metrics ...string) ([]Metric, error) {
+	var r []Metric
+	uri := fmt.Sprintf("/taskmanagers/%s/metrics", url.PathEscape(tmID))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	if len(metrics) > 0 {
+		q := req.URL.Query()
+		q.Add("get", strings.Join(metrics, ","))
+		req.URL.RawQuery = q.Encode()
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// TaskManagerLogs lists the log files of a task manager.
+func (c *Client) TaskManagerLogs(tmID string) (LogsResp, error) {
+	return c.TaskManagerLogsContext(context.Background(), tmID)
+}
+
+// TaskManagerLogsContext is like TaskManagerLogs but
+// carries a context.
+func (c *Client) TaskManagerLogsContext(ctx context.Context, tmID string) (LogsResp, error) {
+	var r LogsResp
+	uri := fmt.Sprintf("/taskmanagers/%s/logs", url.PathEscape(tmID))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// TaskManagerLogFile streams a log file of a task manager
+// listed by TaskManagerLogs. The caller must close the
+// returned reader.
+func (c *Client) TaskManagerLogFile(tmID, name string) (io.ReadCloser, error) {
+	return c.TaskManagerLogFileContext(context.Background(), tmID, name)
+}
+
+// TaskManagerLogFileContext is like TaskManagerLogFile but
+// carries a context.
+func (c *Client) TaskManagerLogFileContext(ctx context.Context, tmID, name string) (io.ReadCloser, error) {
+	uri := fmt.Sprintf("/taskmanagers/%s/logs/%s", url.PathEscape(tmID), url.PathEscape(name))
+	return c.stream(ctx, uri)
+}
+
+// TaskManagerLog streams the main log file of a task
+// manager. The caller must close the returned reader.
+func (c *Client) TaskManagerLog(tmID string) (io.ReadCloser, error) {
+	return c.TaskManagerLogContext(context.Background(), tmID)
+}
+
+// TaskManagerLogContext is like TaskManagerLog but carries
+// a context.
+func (c *Client) TaskManagerLogContext(ctx context.Context, tmID string) (io.ReadCloser, error) {
+	uri := fmt.Sprintf("/taskmanagers/%s/log", url.PathEscape(tmID))
+	return c.stream(ctx, uri)
+}
+
+// TaskManagerStdout streams the stdout file of a task
+// manager. The caller must close the returned reader.
+func (c *Client) TaskManagerStdout(tmID string) (io.ReadCloser, error) {
+	return c.TaskManagerStdoutContext(context.Background(), tmID)
+}
+
+// TaskManagerStdoutContext is like TaskManagerStdout but
+// carries a context.
+func (c *Client) TaskManagerStdoutContext(ctx context.Context, tmID string) (io.ReadCloser, error) {
+	uri := fmt.Sprintf("/taskmanagers/%s/stdout", url.PathEscape(tmID))
+	return c.stream(ctx, uri)
+}
+
+// TaskManagerThreadDump returns a thread dump of a task
+// manager.
+func (c *Client) TaskManagerThreadDump(tmID string) (ThreadDumpResp, error) {
+	return c.TaskManagerThreadDumpContext(context.Background(), tmID)
+}
+
+// TaskManagerThreadDumpContext is like
+// TaskManagerThreadDump but carries a context.
+func (c *Client) TaskManagerThreadDumpContext(ctx context.Context, tmID string) (ThreadDumpResp, error) {
+	var r ThreadDumpResp
+	uri := fmt.Sprintf("/taskmanagers/%s/thread-dump", url.PathEscape(tmID))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// stream GETs uri and returns the response body unread.
+func (c *Client) stream(ctx context.Context, uri string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Stream(req)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"time"
+)
+
+// Timestamp is a point in time sent by flink as epoch
+// milliseconds. Flink uses -1, and sometimes 0, for a time
+// that is not set.
+type Timestamp int64
+
+// IsZero reports whether t is not set.
+func (t Timestamp) IsZero() bool {
+	return t <= 0
+}
+
+// Time returns t as a time.Time, the zero time.Time if t
+// is not set.
+func (t Timestamp) Time() time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(t)*int64(time.Millisecond))
+}
+
+// String formats t as RFC 3339 in UTC, or "-" if t is not
+// set.
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Time().UTC().Format(time.RFC3339Nano)
+}
+
+// Duration is a duration sent by flink in milliseconds.
+// Flink uses -1 for a duration that is not set.
+type Duration int64
+
+// IsSet reports whether d is set.
+func (d Duration) IsSet() bool {
+	return d >= 0
+}
+
+// Duration returns d as a time.Duration, 0 if d is not
+// set.
+func (d Duration) Duration() time.Duration {
+	if !d.IsSet() {
+		return 0
+	}
+	return time.Duration(d) * time.Millisecond
+}
+
+// String formats d like time.Duration, or "-" if d is not
+// set.
+func (d Duration) String() string {
+	if !d.IsSet() {
+		return "-"
+	}
+	return d.Duration().String()
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithTLSConfig sets the TLS configuration used to talk to
+// a flink REST endpoint with security.ssl.rest.enabled.
+// The other TLS options are applied on top of a copy of
+// cfg.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *options) error {
+		if cfg == nil {
+			return errors.New("tls config is nil")
+		}
+		o.tlsConfig = cfg
+		return nil
+	}
+}
+
+// WithCAFile verifies the server certificate against the
+// PEM encoded CA bundle in path instead of the system
+// roots. The file is read again when it changes.
+func WithCAFile(path string) Option {
+	return func(o *options) error {
+		o.caFile = path
+		return nil
+	}
+}
+
+// WithClientCertificate presents the PEM encoded
+// certificate and key in certFile and keyFile for mutual
+// TLS. The files are read again when they change.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(o *options) error {
+		if certFile == "" || keyFile == "" {
+			return errors.New("client certificate and key files are required")
+		}
+		o.certFile = certFile
+		o.keyFile = keyFile
+		return nil
+	}
+}
+
+// WithServerName overrides the name the server certificate
+// is verified against.
+func WithServerName(name string) Option {
+	return func(o *options) error {
+		o.serverName = name
+		return nil
+	}
+}
+
+// tlsEnabled reports whether any TLS option was given, in
+// which case bare addresses default to https.
+func (o options) tlsEnabled() bool {
+	return o.tlsConfig != nil || o.caFile != "" || o.certFile != "" || o.serverName != ""
+}
+
+// tlsTransport returns a copy of rt using the configured
+// TLS settings.
+func (o options) tlsTransport(rt http.RoundTripper) (http.RoundTripper, error) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	base, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("cannot apply TLS options to transport %T", rt)
+	}
+	cfg, err := o.buildTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	tr := base.Clone()
+	tr.TLSClientConfig = cfg
+	if o.caFile == "" {
+		return tr, nil
+	}
+
+	ca := &reloadingFile{paths: []string{o.caFile}, load: loadCertPool}
+	if _, err := ca.get(); err != nil {
+		return nil, err
+	}
+	return &caTransport{base: tr, ca: ca}, nil
+}
+
+func (o options) buildTLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if o.tlsConfig != nil {
+		cfg = o.tlsConfig.Clone()
+	}
+	if o.serverName != "" {
+		cfg.ServerName = o.serverName
+	}
+
+	if o.certFile != "" {
+		cert := &reloadingFile{paths: []string{o.certFile, o.keyFile}, load: loadKeyPair}
+		if _, err := cert.get(); err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			c, err := cert.get()
+			if err != nil {
+				return nil, err
+			}
+			return c.(*tls.Certificate), nil
+		}
+	}
+	return cfg, nil
+}
+
+// caTransport verifies servers against the CA bundle of
+// WithCAFile. The standard verification cannot pick up a
+// rotated bundle, so the transport is rebuilt with the new
+// roots whenever the bundle changes.
+type caTransport struct {
+	base *http.Transport
+	ca   *reloadingFile
+
+	mu   sync.Mutex
+	pool *x509.CertPool
+	tr   *http.Transport
+}
+
+func (t *caTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr, err := t.transport()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return tr.RoundTrip(req)
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections
+// reach the current transport.
+func (t *caTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tr != nil {
+		t.tr.CloseIdleConnections()
+	}
+}
+
+func (t *caTransport) transport() (*http.Transport, error) {
+	v, err := t.ca.get()
+	if err != nil {
+		return nil, err
+	}
+	pool := v.(*x509.CertPool)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tr != nil && t.pool == pool {
+		return t.tr, nil
+	}
+	tr := t.base.Clone()
+	tr.TLSClientConfig.RootCAs = pool
+	if t.tr != nil {
+		// connections made with the old roots are not
+		// reused
+		t.tr.CloseIdleConnections()
+	}
+	t.pool = pool
+	t.tr = tr
+	return tr, nil
+}
+
+func loadCertPool(paths []string) (interface{}, error) {
+	pem, err := ioutil.ReadFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificate found in %s", paths[0])
+	}
+	return pool, nil
+}
+
+func loadKeyPair(paths []string) (interface{}, error) {
+	cert, err := tls.LoadX509KeyPair(paths[0], paths[1])
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// reloadingFile caches what load builds from paths and
+// builds it again once any of the files is modified.
+type reloadingFile struct {
+	paths []string
+	load  func([]string) (interface{}, error)
+
+	mu    sync.Mutex
+	mtime time.Time
+	value interface{}
+}
+
+func (f *reloadingFile) get() (interface{}, error) {
+	var latest time.Time
+	for _, p := range f.paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.value != nil && !latest.After(f.mtime) {
+		return f.value, nil
+	}
+	v, err := f.load(f.paths)
+	if err != nil {
+		if f.value != nil {
+			// keep the previous files while a rotation
+			// is only half written
+			return f.value, nil
+		}
+		return nil, err
+	}
+	f.value = v
+	f.mtime = latest
+	return v, nil
+}
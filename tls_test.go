@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue returns a server certificate for the given IP
+// addresses and DNS names.
+func (ca *testCA) issue(t *testing.T, ips []string, names []string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "jobmanager"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     names,
+	}
+	for _, ip := range ips {
+		tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(ip))
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) writeFile(t *testing.T, path string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, ca.pem, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTLSServer(t *testing.T, cert tls.Certificate) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"refresh-interval":3000}`))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCAFileVerifiesHost(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca.writeFile(t, caFile)
+
+	tests := []struct {
+		name  string
+		ips   []string
+		names []string
+		opts  []Option
+		ok    bool
+	}{
+		{name: "matching ip", ips: []string{"127.0.0.1"}, ok: true},
+		{name: "other ip", ips: []string{"10.9.9.9"}, names: []string{"other.example.com"}},
+		{name: "server name", names: []string{"jm.example.com"}, opts: []Option{WithServerName("jm.example.com")}, ok: true},
+		{name: "wrong server name", names: []string{"jm.example.com"}, opts: []Option{WithServerName("other.example.com")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTLSServer(t, ca.issue(t, tt.ips, tt.names))
+			opts := append([]Option{WithCAFile(caFile), WithRetryPolicy(NoRetry())}, tt.opts...)
+			c, err := New(srv.Listener.Addr().String(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.Config()
+			if tt.ok && err != nil {
+				t.Fatalf("Config() error = %v", err)
+			}
+			if !tt.ok && (err == nil || !strings.Contains(err.Error(), "certificate")) {
+				t.Fatalf("Config() error = %v, want certificate error", err)
+			}
+		})
+	}
+}
+
+func TestCAFileReload(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	other.writeFile(t, caFile)
+
+	srv := newTLSServer(t, ca.issue(t, []string{"127.0.0.1"}, nil))
+	c, err := New(srv.Listener.Addr().String(), WithCAFile(caFile), WithRetryPolicy(NoRetry()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Config(); err == nil {
+		t.Fatal("Config() succeeded with an unrelated CA")
+	}
+
+	ca.writeFile(t, caFile)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(caFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Config(); err != nil {
+		t.Fatalf("Config() after rotation error = %v", err)
+	}
+}
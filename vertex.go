@@ -0,0 +1,499 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// VertexResp is the answer of Vertex.
+type VertexResp struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Parallelism    int             `json:"parallelism"`
+	MaxParallelism int             `json:"maxParallelism"`
+	Now            Timestamp       `json:"now"`
+	Subtasks       []SubtaskDetail `json:"subtasks"`
+}
+
+// SubtaskDetail describes an execution attempt of a
+// subtask. Flink 1.15 and later report Endpoint instead of
+// Host.
+type SubtaskDetail struct {
+	Subtask       int            `json:"subtask"`
+	Status        ExecutionState `json:"status"`
+	Attempt       int            `json:"attempt"`
+	Host          string         `json:"host,omitempty"`
+	Endpoint      string         `json:"endpoint,omitempty"`
+	Start         Timestamp      `json:"start-time"`
+	End           Timestamp      `json:"end-time"`
+	Duration      Duration       `json:"duration"`
+	Metrics       VertexMetrics  `json:"metrics"`
+	TaskManagerID string         `json:"taskmanager-id"`
+
+	// StatusDuration is the time spent in each state, in
+	// milliseconds.
+	StatusDuration map[ExecutionState]Duration `json:"status-duration,omitempty"`
+
+	// OtherConcurrentAttempts are the speculative attempts
+	// running next to this one.
+	OtherConcurrentAttempts []SubtaskDetail `json:"other-concurrent-attempts,omitempty"`
+}
+
+// Vertex returns details of a job vertex and the current
+// attempt of each of its subtasks.
+func (c *Client) Vertex(jobID, vertexID string) (VertexResp, error) {
+	return c.VertexContext(context.Background(), jobID, vertexID)
+}
+
+// VertexContext is like Vertex but carries a context.
+func (c *Client) VertexContext(ctx context.Context, jobID, vertexID string) (VertexResp, error) {
+	var r VertexResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// SubtaskTimesResp is the answer of SubtaskTimes.
+type SubtaskTimesResp struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Now      Timestamp     `json:"now"`
+	Subtasks []SubtaskTime `json:"subtasks"`
+}
+
+// SubtaskTime holds the time a subtask entered each state.
+type SubtaskTime struct {
+	Subtask    int                          `json:"subtask"`
+	Host       string                       `json:"host,omitempty"`
+	Endpoint   string                       `json:"endpoint,omitempty"`
+	Duration   Duration                     `json:"duration"`
+	Timestamps map[ExecutionState]Timestamp `json:"timestamps"`
+}
+
+// SubtaskTimes returns the state transition timestamps of
+// all subtasks of a job vertex.
+func (c *Client) SubtaskTimes(jobID, vertexID string) (SubtaskTimesResp, error) {
+	return c.SubtaskTimesContext(context.Background(), jobID, vertexID)
+}
+
+// SubtaskTimesContext is like SubtaskTimes but carries a
+// context.
+func (c *Client) SubtaskTimesContext(ctx context.Context, jobID, vertexID string) (SubtaskTimesResp, error) {
+	var r SubtaskTimesResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/subtasktimes", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// VertexTaskManagersResp is the answer of
+// VertexTaskManagers.
+type VertexTaskManagersResp struct {
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Now          Timestamp           `json:"now"`
+	TaskManagers []VertexTaskManager `json:"taskmanagers"`
+}
+
+// VertexTaskManager aggregates the subtasks of a vertex
+// running on one task manager.
+type VertexTaskManager struct {
+	Host          string                 `json:"host,omitempty"`
+	Endpoint      string                 `json:"endpoint,omitempty"`
+	Status        ExecutionState         `json:"status"`
+	Start         Timestamp              `json:"start-time"`
+	End           Timestamp              `json:"end-time"`
+	Duration      Duration               `json:"duration"`
+	Metrics       VertexMetrics          `json:"metrics"`
+	StatusCounts  map[ExecutionState]int `json:"status-counts"`
+	TaskManagerID string                 `json:"taskmanager-id"`
+}
+
+// VertexTaskManagers returns the subtasks of a job vertex
+// grouped by task manager.
+func (c *Client) VertexTaskManagers(jobID, vertexID string) (VertexTaskManagersResp, error) {
+	return c.VertexTaskManagersContext(context.Background(), jobID, vertexID)
+}
+
+// VertexTaskManagersContext is like VertexTaskManagers but
+// carries a context.
+func (c *Client) VertexTaskManagersContext(ctx context.Context, jobID, vertexID string) (VertexTaskManagersResp, error) {
+	var r VertexTaskManagersResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/taskmanagers", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// Subtask returns the current execution attempt of a
+// subtask.
+func (c *Client) Subtask(jobID, vertexID string, subtask int) (SubtaskDetail, error) {
+	return c.SubtaskContext(context.Background(), jobID, vertexID, subtask)
+}
+
+// SubtaskContext is like Subtask but carries a context.
+func (c *Client) SubtaskContext(ctx context.Context, jobID, vertexID string, subtask int) (SubtaskDetail, error) {
+	var r SubtaskDetail
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/subtasks/%d", jobID, vertexID, subtask)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// SubtaskAttempt returns an execution attempt of a
+// subtask, the current one or one that was restarted.
+func (c *Client) SubtaskAttempt(jobID, vertexID string, subtask, attempt int) (SubtaskDetail, error) {
+	return c.SubtaskAttemptContext(context.Background(), jobID, vertexID, subtask, attempt)
+}
+
+// SubtaskAttemptContext is like SubtaskAttempt but carries
+// a context.
+func (c *Client) SubtaskAttemptContext(ctx context.Context, jobID, vertexID string, subtask, attempt int) (SubtaskDetail, error) {
+	var r SubtaskDetail
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/subtasks/%d/attempts/%d", jobID, vertexID, subtask, attempt)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// SubtaskAccumulatorsResp is the answer of
+// SubtaskAccumulators.
+type SubtaskAccumulatorsResp struct {
+	ID          string                `json:"id"`
+	Parallelism int                   `json:"parallelism"`
+	Subtasks    []SubtaskAccumulators `json:"subtasks"`
+}
+
+// SubtaskAccumulators holds the user accumulators of a
+// subtask.
+type SubtaskAccumulators struct {
+	Subtask          int               `json:"subtask"`
+	Attempt          int               `json:"attempt"`
+	Host             string            `json:"host,omitempty"`
+	Endpoint         string            `json:"endpoint,omitempty"`
+	UserAccumulators []UserAccumulator `json:"user-accumulators"`
+}
+
+// UserAccumulator is an accumulator registered by user
+// code. Value is its string representation.
+type UserAccumulator struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// SubtaskAccumulators returns the user accumulators of all
+// subtasks of a job vertex.
+func (c *Client) SubtaskAccumulators(jobID, vertexID string) (SubtaskAccumulatorsResp, error) {
+	return c.SubtaskAccumulatorsContext(context.Background(), jobID, vertexID)
+}
+
+// SubtaskAccumulatorsContext is like SubtaskAccumulators
+// but carries a context.
+func (c *Client) SubtaskAccumulatorsContext(ctx context.Context, jobID, vertexID string) (SubtaskAccumulatorsResp, error) {
+	var r SubtaskAccumulatorsResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/subtasks/accumulators", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// BackPressure levels reported by Flink.
+const (
+	BackPressureOK   = "ok"
+	BackPressureLow  = "low"
+	BackPressureHigh = "high"
+)
+
+// BackPressureResp is the answer of BackPressure. Status
+// is "deprecated" while Flink versions before 1.13 are
+// still sampling the vertex, in which case the other
+// fields are empty.
+type BackPressureResp struct {
+	Status       string                `json:"status"`
+	Level        string                `json:"backpressure-level"`
+	EndTimestamp Timestamp             `json:"end-timestamp"`
+	Subtasks     []SubtaskBackPressure `json:"subtasks"`
+}
+
+// Ready reports whether the answer holds a back pressure
+// sample.
+func (r BackPressureResp) Ready() bool {
+	return r.Status == "ok"
+}
+
+// SubtaskBackPressure is the back pressure of one subtask.
+// The ratios are between 0 and 1.
+type SubtaskBackPressure struct {
+	Subtask       int     `json:"subtask"`
+	AttemptNumber int     `json:"attempt-number"`
+	Level         string  `json:"backpressure-level"`
+	Ratio         float64 `json:"ratio"`
+	IdleRatio     float64 `json:"idleRatio"`
+	BusyRatio     float64 `json:"busyRatio"`
+
+	OtherConcurrentAttempts []SubtaskBackPressure `json:"other-concurrent-attempts,omitempty"`
+}
+
+// BackPressure returns the back pressure of a job vertex.
+// On older Flink versions the first call only starts the
+// sampling, see WaitBackPressure.
+func (c *Client) BackPressure(jobID, vertexID string) (BackPressureResp, error) {
+	return c.BackPressureContext(context.Background(), jobID, vertexID)
+}
+
+// BackPressureContext is like BackPressure but carries a
+// context.
+func (c *Client) BackPressureContext(ctx context.Context, jobID, vertexID string) (BackPressureResp, error) {
+	var r BackPressureResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/backpressure", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// WaitBackPressure calls BackPressure every pollInterval
+// until a sample is available or ctx is done.
+func (c *Client) WaitBackPressure(ctx context.Context, jobID, vertexID string, pollInterval time.Duration) (BackPressureResp, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	for {
+		r, err := c.BackPressureContext(ctx, jobID, vertexID)
+		if err != nil || r.Ready() {
+			return r, err
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return r, err
+		}
+	}
+}
+
+// Watermarks returns the current input watermark of each
+// subtask of a job vertex, as metrics named
+// "<subtask>.currentInputWatermark".
+func (c *Client) Watermarks(jobID, vertexID string) ([]Metric, error) {
+	return c.WatermarksContext(context.Background(), jobID, vertexID)
+}
+
+// WatermarksContext is like Watermarks but carries a
+// context.
+func (c *Client) WatermarksContext(ctx context.Context, jobID, vertexID string) ([]Metric, error) {
+	var r []Metric
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/watermarks", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// FlameGraphType selects the threads sampled for a flame
+// graph.
+type FlameGraphType string
+
+// Flame graph types.
+const (
+	FlameGraphFull   FlameGraphType = "full"
+	FlameGraphOnCPU  FlameGraphType = "on_cpu"
+	FlameGraphOffCPU FlameGraphType = "off_cpu"
+)
+
+// FlameGraphResp is the answer of FlameGraph. Data is nil
+// until a sample has been taken; EndTimestamp then tells
+// why, see FlameGraphWaiting, FlameGraphTerminated and
+// FlameGraphDisabled. Flame graphs need
+// rest.flamegraph.enabled on the cluster.
+type FlameGraphResp struct {
+	EndTimestamp Timestamp       `json:"endTimestamp"`
+	Data         *FlameGraphNode `json:"data"`
+}
+
+// EndTimestamp values of a FlameGraphResp without data.
+const (
+	// FlameGraphTerminated: the vertex is no longer
+	// running.
+	FlameGraphTerminated Timestamp = -1
+	// FlameGraphDisabled: rest.flamegraph.enabled is off.
+	FlameGraphDisabled Timestamp = -2
+	// FlameGraphWaiting: the sample is still being taken.
+	FlameGraphWaiting Timestamp = -3
+)
+
+// Ready reports whether the answer holds a flame graph.
+func (r FlameGraphResp) Ready() bool {
+	return r.Data != nil
+}
+
+// Unavailable reports whether flink will not sample the
+// vertex, so that polling for the flame graph is useless.
+func (r FlameGraphResp) Unavailable() bool {
+	return r.Data == nil &&
+		(r.EndTimestamp == FlameGraphTerminated || r.EndTimestamp == FlameGraphDisabled)
+}
+
+// ErrFlameGraphUnavailable is returned by WaitFlameGraph
+// when flink will not sample the vertex.
+var ErrFlameGraphUnavailable = errors.New("flame graph unavailable")
+
+// FlameGraphNode is a stack frame and the number of
+// samples it was seen in.
+type FlameGraphNode struct {
+	Name     string           `json:"name"`
+	Value    int64            `json:"value"`
+	Children []FlameGraphNode `json:"children,omitempty"`
+}
+
+// FlameGraph returns a flame graph of a job vertex. The
+// first call starts the sampling, see WaitFlameGraph.
+func (c *Client) FlameGraph(jobID, vertexID string, typ FlameGraphType) (FlameGraphResp, error) {
+	return c.FlameGraphContext(context.Background(), jobID, vertexID, typ)
+}
+
+// FlameGraphContext is like FlameGraph but carries a
+// context.
+func (c *Client) FlameGraphContext(ctx context.Context, jobID, vertexID string, typ FlameGraphType) (FlameGraphResp, error) {
+	var r FlameGraphResp
+	uri := fmt.Sprintf("/jobs/%s/vertices/%s/flamegraph", jobID, vertexID)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		c.url(uri),
+		nil,
+	)
+	if err != nil {
+		return r, err
+	}
+	if typ != "" {
+		q := req.URL.Query()
+		q.Add("type", string(typ))
+		req.URL.RawQuery = q.Encode()
+	}
+	b, err := c.client.Do(req)
+	if err != nil {
+		return r, err
+	}
+	err = json.Unmarshal(b, &r)
+	return r, err
+}
+
+// WaitFlameGraph calls FlameGraph every pollInterval until
+// the flame graph is available or ctx is done. It returns
+// ErrFlameGraphUnavailable if the vertex is no longer
+// running or flame graphs are disabled.
+func (c *Client) WaitFlameGraph(ctx context.Context, jobID, vertexID string, typ FlameGraphType, pollInterval time.Duration) (FlameGraphResp, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	for {
+		r, err := c.FlameGraphContext(ctx, jobID, vertexID, typ)
+		if err != nil || r.Ready() {
+			return r, err
+		}
+		if r.Unavailable() {
+			return r, ErrFlameGraphUnavailable
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return r, err
+		}
+	}
+}
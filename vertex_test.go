@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWaitFlameGraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "ready",
+			responses: []string{`{"endTimestamp":-3,"data":null}`, `{"endTimestamp":-3,"data":null}`, `{"endTimestamp":1718000612000,"data":{"name":"root","value":1}}`},
+			wantCalls: 3,
+		},
+		{
+			name:      "terminated",
+			responses: []string{`{"endTimestamp":-3,"data":null}`, `{"endTimestamp":-1,"data":null}`},
+			wantErr:   ErrFlameGraphUnavailable,
+			wantCalls: 2,
+		},
+		{
+			name:      "disabled",
+			responses: []string{`{"endTimestamp":-2,"data":null}`},
+			wantErr:   ErrFlameGraphUnavailable,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var responses []scriptedResponse
+			for _, body := range tt.responses {
+				responses = append(responses, scriptedResponse{status: http.StatusOK, body: body})
+			}
+			srv := newScriptedServer(t, responses...)
+			c, err := New(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			r, err := c.WaitFlameGraph(ctx, testJobID, testVertexID, FlameGraphFull, time.Millisecond)
+			if err != tt.wantErr {
+				t.Fatalf("WaitFlameGraph() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !r.Ready() {
+				t.Errorf("WaitFlameGraph() = %+v, want a flame graph", r)
+			}
+			if srv.hits() != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", srv.hits(), tt.wantCalls)
+			}
+		})
+	}
+}